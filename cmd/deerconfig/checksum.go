@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"io"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+)
+
+var _checksum = command{
+	name:  "checksum",
+	args:  "[-algorithm name] file...",
+	short: "write the checksum files of configuration files",
+	run:   checksum,
+}
+
+// checksum writes the checksum file of every given file.
+func checksum(fs *flag.FlagSet, args []string, _ io.Writer) error {
+	algorithm := fs.String("algorithm", "md5", "checksum `name`: md5, sha256 or sha512")
+
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+
+	for _, filename := range fs.Args() {
+		if err := config.WriteChecksumFile(filename, *algorithm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
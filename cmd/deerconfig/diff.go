@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+)
+
+var _diff = command{
+	name:  "diff",
+	args:  "old new",
+	short: "compare the properties of two configuration files",
+	run:   diff,
+}
+
+// diff prints the properties removed, added or changed from the old file to
+// the new one, sorted by key: removed and old values are prefixed by "-", added
+// and new values by "+", escaped as in a properties file. Values are compared as
+// written, without interpolating nor decrypting them, and the files are not
+// verified, so that a file being edited can be compared to the deployed one.
+func diff(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+
+	oldValues, err := config.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	newValues, err := config.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(oldValues)+len(newValues))
+	for k := range oldValues {
+		keys = append(keys, k)
+	}
+
+	for k := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	different := false
+
+	for _, k := range keys {
+		o, inOld := oldValues[k]
+		n, inNew := newValues[k]
+
+		if inOld && inNew && o == n {
+			continue
+		}
+
+		if inOld {
+			fmt.Fprint(stdout, "-"+config.FormatProperties(map[string]string{k: o}))
+		}
+
+		if inNew {
+			fmt.Fprint(stdout, "+"+config.FormatProperties(map[string]string{k: n}))
+		}
+
+		different = true
+	}
+
+	if different {
+		return errDifferent
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+)
+
+var _dump = command{
+	name:  "dump",
+	args:  "[-json] file",
+	short: "print every property",
+	run:   dump,
+}
+
+// dump prints the effective value of every property, sorted by key, as an
+// escaped properties file or as a JSON object.
+func dump(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	asJSON := fs.Bool("json", false, "print a JSON object")
+
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	cfg, err := loadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(cfg.GetAll())
+	}
+
+	_, err = io.WriteString(stdout, config.FormatProperties(cfg.GetAll()))
+
+	return err
+}
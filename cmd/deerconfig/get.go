@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+)
+
+var _get = command{
+	name:  "get",
+	args:  "[-type name] file key",
+	short: "print the value of a property",
+	run:   get,
+}
+
+// _getters read a property with the getter of the given type, returning the
+// values to print, one per line.
+var _getters = map[string]func(cfg *config.Config, key string) ([]string, error){
+	"string": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupString(key)
+		return []string{v}, err
+	},
+	"bool": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupBool(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"int": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupInt(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"uint": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupUint(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"float": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupFloat64(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"duration": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupDuration(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"parsed-duration": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupParsedDuration(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"list": func(cfg *config.Config, key string) ([]string, error) {
+		return cfg.LookupStringSlice(key)
+	},
+	"int-list": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupIntSlice(key)
+
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = fmt.Sprint(e)
+		}
+
+		return values, err
+	},
+	"float-list": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupFloatSlice(key)
+
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = fmt.Sprint(e)
+		}
+
+		return values, err
+	},
+	"byte-size": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupByteSize(key)
+		return []string{fmt.Sprint(v)}, err
+	},
+	"url": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupURL(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{v.String()}, nil
+	},
+	"ip": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupIP(key)
+		return []string{v.String()}, err
+	},
+	"cidr": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupCIDR(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{v.String()}, nil
+	},
+	"time": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupTime(key)
+		return []string{v.Format(time.RFC3339)}, err
+	},
+	"bool-list": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupBoolSlice(key)
+
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = fmt.Sprint(e)
+		}
+
+		return values, err
+	},
+	"duration-list": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupDurationSlice(key)
+
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = fmt.Sprint(e)
+		}
+
+		return values, err
+	},
+	"map": func(cfg *config.Config, key string) ([]string, error) {
+		v, err := cfg.LookupStringMap(key)
+
+		values := make([]string, 0, len(v))
+		for k, e := range v {
+			values = append(values, k+"="+e)
+		}
+
+		sort.Strings(values)
+
+		return values, err
+	},
+	"json": func(cfg *config.Config, key string) ([]string, error) {
+		var v interface{}
+		if err := cfg.GetJSONPropertyAndUnmarshal(key, &v); err != nil {
+			return nil, err
+		}
+
+		b, err := json.MarshalIndent(v, "", "  ")
+
+		return []string{string(b)}, err
+	},
+}
+
+// get prints the value of a property read with the getter of the given type.
+// List values are printed one per line.
+func get(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	typ := fs.String("type", "string", "getter `name`: "+getterNames())
+
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+
+	getter, ok := _getters[*typ]
+	if !ok {
+		return fmt.Errorf("unknown type %q, expected one of %s", *typ, getterNames())
+	}
+
+	cfg, err := loadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	values, err := getter(cfg, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		fmt.Fprintln(stdout, v)
+	}
+
+	return nil
+}
+
+func getterNames() string {
+	names := make([]string, 0, len(_getters))
+	for name := range _getters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
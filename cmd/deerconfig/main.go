@@ -0,0 +1,153 @@
+// Command deerconfig validates, checksums and inspects configuration files the
+// way the config package loads them.
+//
+// Usage:
+//
+//	deerconfig <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	validate   parse configuration files and verify their checksum
+//	checksum   write the checksum files of configuration files
+//	get        print the value of a property
+//	dump       print every property
+//	diff       compare the properties of two configuration files
+//	set        set or delete a property, rewriting the checksum files
+//
+// Files are loaded on their own: the scope files and directories configured in
+// the environment are ignored. The other environment variables of the config
+// package still apply, checksums being verified unless checksumEnabled is
+// "false", and encrypted values being decrypted with configEncryptionKey or
+// configEncryptionKeyFile, except by set which leaves them as written. diff
+// compares the files as written, without verifying them.
+//
+// deerconfig exits with status 0 on success, 1 on failure and 2 on invalid
+// arguments, except for diff which, like diff(1), exits with status 1 when the
+// files differ and 2 on failure.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+	"go.uber.org/multierr"
+)
+
+// command is a deerconfig subcommand. run defines the flags of the command on
+// fs, parses args with it and writes its output to stdout.
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(fs *flag.FlagSet, args []string, stdout io.Writer) error
+}
+
+var _commands = []command{
+	_validate,
+	_checksum,
+	_get,
+	_dump,
+	_diff,
+	_set,
+}
+
+// errUsage is reported when the arguments of a command are invalid.
+var errUsage = errors.New("invalid arguments")
+
+// errDifferent is reported by diff when the files differ.
+var errDifferent = errors.New("files differ")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command described by args, returning the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	for _, c := range _commands {
+		if c.name != args[0] {
+			continue
+		}
+
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "usage: deerconfig %s %s\n", c.name, c.args)
+			fs.PrintDefaults()
+		}
+
+		err := c.run(fs, args[1:], stdout)
+
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			fs.Usage()
+			return 2
+		case errors.Is(err, errDifferent):
+			return 1
+		}
+
+		for _, e := range multierr.Errors(err) {
+			fmt.Fprintf(stderr, "deerconfig %s: %v\n", c.name, e)
+		}
+
+		if c.name == _diff.name {
+			return 2
+		}
+
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "deerconfig: unknown command %q\n", args[0])
+	usage(stderr)
+
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: deerconfig <command> [flags] [arguments]\n\ncommands:\n")
+
+	for _, c := range _commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.short)
+	}
+}
+
+// parse parses args with fs, making sure n positional arguments are left, or
+// at least one when n is negative.
+func parse(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return errUsage
+	}
+
+	if (n < 0 && fs.NArg() == 0) || (n >= 0 && fs.NArg() != n) {
+		return errUsage
+	}
+
+	return nil
+}
+
+// loadFile loads the given configuration file on its own.
+func loadFile(filename string) (*config.Config, error) {
+	return config.LoadFile(filename, config.WithScope(), config.WithDir())
+}
+
+// loadRaw loads the given configuration file on its own, as loadFile does,
+// leaving its encrypted values as written.
+func loadRaw(filename string) (*config.Config, error) {
+	return config.LoadFile(filename, config.WithScope(), config.WithDir(), config.WithoutDecryption())
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0o600))
+	require.NoError(t, config.WriteChecksumFile(filename, "md5"))
+
+	return filename
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("checksumEnabled", "true")
+
+	dir := t.TempDir()
+	oldFile := writeFile(t, dir, "old.properties", "db.host=localhost\n"+
+		"db.url=jdbc://${db.host}/x\n"+
+		"ids=1,2,3\n"+
+		"removed=true\n"+
+		`car={"id":10,"model":"Gol"}`+"\n")
+	newFile := writeFile(t, dir, "new.properties", "db.host=db.internal\n"+
+		"db.url=jdbc://${db.host}/x\n"+
+		"ids=1,2,3\n"+
+		"added=true\n"+
+		`car={"id":10,"model":"Gol"}`+"\n")
+
+	unverified := filepath.Join(dir, "unverified.properties")
+	require.NoError(t, ioutil.WriteFile(unverified, []byte("string=value\n"), 0o600))
+
+	edited := filepath.Join(dir, "edited.properties")
+	require.NoError(t, ioutil.WriteFile(edited, []byte("db.host=localhost\n"+
+		"db.url=jdbc://${db.host}/${db.name}\n"+
+		"ids=1,2,3\n"+
+		"removed=true\n"+
+		`car={"id":10,"model":"Gol"}`+"\n"), 0o600))
+
+	missing := filepath.Join(dir, "missing.properties")
+
+	tt := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "validate",
+			args:   []string{"validate", oldFile, newFile},
+			stdout: oldFile + ": ok\n" + newFile + ": ok\n",
+		},
+		{
+			name:   "validate failure",
+			args:   []string{"validate", oldFile, unverified},
+			code:   1,
+			stdout: oldFile + ": ok\n",
+			stderr: "deerconfig validate: " + unverified + ": verifying configuration: open " + unverified + ".md5: no such file or directory\n",
+		},
+		{
+			name:   "get",
+			args:   []string{"get", oldFile, "db.url"},
+			stdout: "jdbc://localhost/x\n",
+		},
+		{
+			name:   "get list",
+			args:   []string{"get", "-type", "int-list", oldFile, "ids"},
+			stdout: "1\n2\n3\n",
+		},
+		{
+			name:   "get url",
+			args:   []string{"get", "-type", "url", oldFile, "db.url"},
+			stdout: "jdbc://localhost/x\n",
+		},
+		{
+			name:   "get json",
+			args:   []string{"get", "-type=json", oldFile, "car"},
+			stdout: "{\n  \"id\": 10,\n  \"model\": \"Gol\"\n}\n",
+		},
+		{
+			name:   "get missing",
+			args:   []string{"get", oldFile, "missing"},
+			code:   1,
+			stderr: "deerconfig get: key missing: not found\n",
+		},
+		{
+			name:   "get malformed",
+			args:   []string{"get", "-type", "int", oldFile, "db.host"},
+			code:   1,
+			stderr: "deerconfig get: key db.host: malformed value \"localhost\": strconv.Atoi: parsing \"localhost\": invalid syntax\n",
+		},
+		{
+			name:   "dump",
+			args:   []string{"dump", newFile},
+			stdout: "added=true\ncar={\"id\":10,\"model\":\"Gol\"}\ndb.host=db.internal\ndb.url=jdbc://db.internal/x\nids=1,2,3\n",
+		},
+		{
+			name:   "dump json",
+			args:   []string{"dump", "--json", oldFile},
+			stdout: "{\n  \"car\": \"{\\\"id\\\":10,\\\"model\\\":\\\"Gol\\\"}\",\n  \"db.host\": \"localhost\",\n  \"db.url\": \"jdbc://localhost/x\",\n  \"ids\": \"1,2,3\",\n  \"removed\": \"true\"\n}\n",
+		},
+		{
+			name:   "diff",
+			args:   []string{"diff", oldFile, newFile},
+			code:   1,
+			stdout: "+added=true\n-db.host=localhost\n+db.host=db.internal\n-removed=true\n",
+		},
+		{
+			name: "diff same",
+			args: []string{"diff", oldFile, oldFile},
+		},
+		{
+			name:   "diff unverified",
+			args:   []string{"diff", oldFile, edited},
+			code:   1,
+			stdout: "-db.url=jdbc://${db.host}/x\n+db.url=jdbc://${db.host}/${db.name}\n",
+		},
+		{
+			name:   "diff failure",
+			args:   []string{"diff", oldFile, missing},
+			code:   2,
+			stderr: "deerconfig diff: reading configuration: open " + missing + ": no such file or directory\n",
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"lint"},
+			code:   2,
+			stderr: "deerconfig: unknown command \"lint\"\n",
+		},
+		{
+			name:   "invalid arguments",
+			args:   []string{"get", oldFile},
+			code:   2,
+			stderr: "usage: deerconfig get [-type name] file key\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			code := run(tc.args, &stdout, &stderr)
+
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Equal(t, tc.stdout, stdout.String())
+			require.True(t, strings.HasPrefix(stderr.String(), tc.stderr), stderr.String())
+		})
+	}
+}
+
+func TestRun_checksum(t *testing.T) {
+	t.Setenv("checksumEnabled", "true")
+
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("string=value\n"), 0o600))
+
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, run([]string{"checksum", "-algorithm", "sha256", filename}, &stdout, &stderr), stderr.String())
+	require.Equal(t, 0, run([]string{"validate", filename}, &stdout, &stderr), stderr.String())
+
+	sum, err := ioutil.ReadFile(filename + ".sha256")
+	require.NoError(t, err)
+	require.Equal(t, "cb741839a010b2269ecc85a10c6d90e99592f058213cd78179887bf82d02cbf0", string(sum))
+}
+
+func TestRun_encrypted(t *testing.T) {
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configEncryptionKey", "")
+	t.Setenv("configEncryptionKeyFile", "")
+
+	key, err := config.GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	oldPassword, err := config.EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+
+	newPassword, err := config.EncryptValue(key, "rotated")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	oldFile := writeFile(t, dir, "old.properties", "db.password="+oldPassword+"\n")
+	newFile := writeFile(t, dir, "new.properties", "db.password="+newPassword+"\n")
+
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 1, run([]string{"diff", oldFile, newFile}, &stdout, &stderr), stderr.String())
+	require.Equal(t, "-db.password="+oldPassword+"\n+db.password="+newPassword+"\n", stdout.String())
+
+	require.Equal(t, 0, run([]string{"set", oldFile, "db.user", "app"}, &stdout, &stderr), stderr.String())
+
+	b, err := ioutil.ReadFile(oldFile)
+	require.NoError(t, err)
+	require.Equal(t, "db.password="+oldPassword+"\ndb.user=app\n", string(b))
+}
+
+func TestRun_dumpEscaped(t *testing.T) {
+	t.Setenv("checksumEnabled", "true")
+
+	dir := t.TempDir()
+	filename := writeFile(t, dir, "application.properties", "motd=line 1\\nline 2\npadded=\\  x\nkey\\=with\\:separators=v\n")
+
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, run([]string{"dump", filename}, &stdout, &stderr), stderr.String())
+
+	dumped := writeFile(t, dir, "dumped.properties", stdout.String())
+	original, err := config.LoadFile(filename, config.WithScope(), config.WithDir())
+	require.NoError(t, err)
+
+	reloaded, err := config.LoadFile(dumped, config.WithScope(), config.WithDir())
+	require.NoError(t, err)
+	require.Equal(t, original.GetAll(), reloaded.GetAll())
+	require.Equal(t, "line 1\nline 2", reloaded.GetString("motd", ""))
+}
+
+func TestRun_set(t *testing.T) {
+	t.Setenv("checksumEnabled", "true")
+
+	filename := writeFile(t, t.TempDir(), "application.properties", "# Database\ndb.host=localhost\nremoved=true\n")
+
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, run([]string{"set", filename, "db.host", "db.internal"}, &stdout, &stderr), stderr.String())
+	require.Equal(t, 0, run([]string{"set", "-delete", filename, "removed"}, &stdout, &stderr), stderr.String())
+	require.Equal(t, 0, run([]string{"validate", filename}, &stdout, &stderr), stderr.String())
+	require.Equal(t, 2, run([]string{"set", "-delete", filename, "db.host", "x"}, &stdout, &stderr))
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "# Database\ndb.host=db.internal\n", string(b))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"io"
+)
+
+var _set = command{
+	name:  "set",
+	args:  "file key value | -delete file key",
+	short: "set or delete a property, rewriting the checksum files",
+	run:   set,
+}
+
+// set sets or deletes a property of a properties file, keeping its comments
+// and the order of its keys, and writes its checksum files again. Encrypted
+// values are left as written, so that no encryption key is needed.
+func set(fs *flag.FlagSet, args []string, _ io.Writer) error {
+	del := fs.Bool("delete", false, "delete the property")
+
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+
+	if (*del && fs.NArg() != 2) || (!*del && fs.NArg() != 3) {
+		return errUsage
+	}
+
+	cfg, err := loadRaw(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *del {
+		err = cfg.Delete(fs.Arg(1))
+	} else {
+		err = cfg.Set(fs.Arg(1), fs.Arg(2))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return cfg.Save()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"go.uber.org/multierr"
+)
+
+var _validate = command{
+	name:  "validate",
+	args:  "file...",
+	short: "parse configuration files and verify their checksum",
+	run:   validate,
+}
+
+// validate loads every given file, reporting those that can't be loaded.
+func validate(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, filename := range fs.Args() {
+		if _, err := loadFile(filename); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", filename, err))
+			continue
+		}
+
+		fmt.Fprintf(stdout, "%s: ok\n", filename)
+	}
+
+	return multierr.Combine(errs...)
+}
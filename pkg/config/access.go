@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+)
+
+// WithAccessTracking records the reads of the configuration made through its
+// getters, see AccessReport.
+func WithAccessTracking() Option {
+	return func(c *loadConfig) {
+		c.tracking = true
+	}
+}
+
+// KeyAccess reports how a key was read, see AccessReport.
+type KeyAccess struct {
+	Key string
+	// Reads is the number of times the key was read through a getter.
+	Reads int
+	// Missing and Malformed are the number of reads that found the key
+	// missing, or its value malformed or unresolvable, the Get getters then
+	// returning their default value and the Lookup ones an error.
+	Missing   int
+	Malformed int
+	// Referenced is the number of times the key was referenced by the value
+	// of a key read.
+	Referenced int
+}
+
+// AccessReport reports the reads of a configuration since it was loaded.
+type AccessReport struct {
+	// Keys are the keys read or referenced, sorted, including the missing
+	// ones.
+	Keys []KeyAccess
+	// Unused are the keys of the configuration that were neither read nor
+	// referenced, sorted.
+	Unused []string
+}
+
+// tracker records the reads of a configuration.
+type tracker struct {
+	mu   sync.Mutex
+	keys map[string]*KeyAccess
+}
+
+func newTracker() *tracker {
+	return &tracker{keys: map[string]*KeyAccess{}}
+}
+
+// access returns the record of key. t.mu must be held.
+func (t *tracker) access(key string) *KeyAccess {
+	a, ok := t.keys[key]
+	if !ok {
+		a = &KeyAccess{Key: key}
+		t.keys[key] = a
+	}
+
+	return a
+}
+
+// track records the read of key from s, err being the error of the lookup.
+// The properties its value references are recorded as referenced, in turn.
+func (p *Config) track(s *snapshot, key string, err error) {
+	t := p.base().tracker
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a := t.access(key)
+	a.Reads++
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		a.Missing++
+		return
+	case err != nil:
+		a.Malformed++
+	}
+
+	// The value may come from a deprecated name of the key, see Alias.
+	if _, _, from := p.rawAlias(s, key); from != key {
+		t.access(from).Reads++
+	}
+
+	p.trackReferences(t, s, key, map[string]bool{key: true})
+}
+
+// trackReferences records the properties referenced by the value of key as
+// referenced, recursively. seen holds the keys already visited, to stop at
+// circular references. t.mu must be held.
+func (p *Config) trackReferences(t *tracker, s *snapshot, key string, seen map[string]bool) {
+	v, _ := p.raw(s, key)
+
+	for _, ref := range references(v) {
+		if strings.HasPrefix(ref, _refEnv) || seen[ref] {
+			continue
+		}
+
+		seen[ref] = true
+
+		if _, ok := p.raw(s, ref); !ok {
+			continue
+		}
+
+		t.access(ref).Referenced++
+		p.trackReferences(t, s, ref, seen)
+	}
+}
+
+// reject records that the value of key, read by a getter, can't be parsed and
+// returns the error reporting it, see parseError.
+func (p *Config) reject(key, value string, err error) error {
+	if t := p.base().tracker; t != nil {
+		t.mu.Lock()
+		t.access(p.key(key)).Malformed++
+		t.mu.Unlock()
+	}
+
+	return p.parseError(key, value, err)
+}
+
+// AccessReport reports the keys read since the configuration was loaded, with
+// how their reads went, and the keys never read, for properties that are no
+// longer used to be removed. Keys are the full keys of the configuration, even
+// when called on a view created by Sub. It is empty unless the configuration
+// was loaded with WithAccessTracking.
+func (p *Config) AccessReport() AccessReport {
+	var r AccessReport
+
+	t := p.base().tracker
+	if t == nil {
+		return r
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, a := range t.keys {
+		r.Keys = append(r.Keys, *a)
+	}
+
+	sort.Slice(r.Keys, func(i, j int) bool {
+		return r.Keys[i].Key < r.Keys[j].Key
+	})
+
+	for _, k := range p.props().Keys() {
+		if _, ok := t.keys[k]; !ok {
+			r.Unused = append(r.Unused, k)
+		}
+	}
+
+	sort.Strings(r.Unused)
+
+	return r
+}
+
+// LogAccessReport logs a summary of the AccessReport at info level, listing
+// the keys never read and the keys read while missing or malformed. It is
+// meant to be called when the application shuts down.
+func (p *Config) LogAccessReport() {
+	r := p.AccessReport()
+
+	var missing, malformed []string
+
+	for _, a := range r.Keys {
+		if a.Missing > 0 {
+			missing = append(missing, a.Key)
+		}
+
+		if a.Malformed > 0 {
+			malformed = append(malformed, a.Key)
+		}
+	}
+
+	p.base().getLogger().Info("configuration access report",
+		log.Int("read", len(r.Keys)),
+		log.Strings("unused", r.Unused),
+		log.Strings("missing", missing),
+		log.Strings("malformed", malformed),
+	)
+}
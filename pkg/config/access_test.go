@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAccessReport(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{
+		"db.host":    "localhost",
+		"db.port":    "not a port",
+		"db.url":     "jdbc://${db.host}:${db.port}/x",
+		"db.timeout": "5s",
+		"unused":     "value",
+	}, WithAccessTracking())
+
+	// When
+	require.Equal(t, "localhost", cfg.GetString("db.host", ""))
+	require.Equal(t, "localhost", cfg.GetString("db.host", ""))
+	require.Equal(t, 5432, cfg.GetInt("db.port", 5432))
+	require.Equal(t, "jdbc://localhost:not a port/x", cfg.GetString("db.url", ""))
+	require.Equal(t, 10, cfg.GetInt("missing", 10))
+
+	_, err := cfg.LookupString("missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Then
+	require.Equal(t, AccessReport{
+		Keys: []KeyAccess{
+			{Key: "db.host", Reads: 2, Referenced: 1},
+			{Key: "db.port", Reads: 1, Malformed: 1, Referenced: 1},
+			{Key: "db.url", Reads: 1},
+			{Key: "missing", Reads: 2, Missing: 2},
+		},
+		Unused: []string{"db.timeout", "unused"},
+	}, cfg.AccessReport())
+}
+
+func TestAccessReport_sub(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{
+		"db.host": "localhost",
+		"db.port": "5432",
+	}, WithAccessTracking())
+
+	// When
+	require.Equal(t, 5432, cfg.Sub("db").GetInt("port", 0))
+
+	// Then
+	r := cfg.Sub("db").AccessReport()
+	require.Equal(t, []KeyAccess{{Key: "db.port", Reads: 1}}, r.Keys)
+	require.Equal(t, []string{"db.host"}, r.Unused)
+}
+
+func TestAccessReport_alias(t *testing.T) {
+	// Given
+	lvl := log.NewAtomicLevelAt(log.WarnLevel)
+	logger := log.NewProductionLogger(&lvl, log.WithWriter(zapcore.AddSync(&bytes.Buffer{})))
+
+	cfg := LoadMap(map[string]string{"db.poolSize": "10"}, WithAccessTracking(), WithLogger(logger))
+	cfg.Alias("db.poolSize", "db.pool.size")
+
+	// When
+	require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+
+	// Then
+	r := cfg.AccessReport()
+	require.Equal(t, []KeyAccess{
+		{Key: "db.pool.size", Reads: 1},
+		{Key: "db.poolSize", Reads: 1},
+	}, r.Keys)
+	require.Empty(t, r.Unused)
+}
+
+func TestAccessReport_disabled(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"key": "value"})
+
+	// When
+	require.Equal(t, "value", cfg.GetString("key", ""))
+
+	// Then
+	require.Equal(t, AccessReport{}, cfg.AccessReport())
+}
+
+func TestAccessReport_dump(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"key": "value"}, WithAccessTracking())
+
+	// When
+	require.Len(t, cfg.GetAll(), 1)
+
+	// Then
+	require.Equal(t, []string{"key"}, cfg.AccessReport().Unused)
+}
+
+func TestLogAccessReport(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "port=x\nhost=localhost\nunused=value\n")
+
+	var buf bytes.Buffer
+	lvl := log.NewAtomicLevelAt(log.InfoLevel)
+	logger := log.NewProductionLogger(&lvl, log.WithWriter(zapcore.AddSync(&buf)), log.WithJSONEncoding())
+
+	cfg, err := LoadFile(filename, WithLogger(logger), WithAccessTracking())
+	require.NoError(t, err)
+
+	require.Equal(t, "localhost", cfg.GetString("host", ""))
+	require.Equal(t, 80, cfg.GetInt("port", 80))
+	require.Equal(t, "", cfg.GetString("missing", ""))
+
+	// When
+	cfg.LogAccessReport()
+
+	// Then
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "configuration access report", entry["msg"])
+	require.EqualValues(t, 3, entry["read"])
+	require.Equal(t, []interface{}{"unused"}, entry["unused"])
+	require.Equal(t, []interface{}{"missing"}, entry["missing"])
+	require.Equal(t, []interface{}{"port"}, entry["malformed"])
+}
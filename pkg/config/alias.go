@@ -0,0 +1,262 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+)
+
+// _package is the import path of this package, to tell its frames from the
+// caller's ones.
+var _package = reflect.TypeOf(Config{}).PkgPath()
+
+// WithLogger sets the logger warnings are logged to, such as reads of
+// deprecated keys, see Alias. It defaults to log.DefaultLogger.
+func WithLogger(l log.Logger) Option {
+	return func(c *loadConfig) {
+		c.logger = l
+	}
+}
+
+// WithAlias registers oldKey as a deprecated name of newKey, as Alias does, but
+// before the configuration is first read. Schema checks, see WithSchema, then
+// accept oldKey as newKey on load and on every reload.
+func WithAlias(oldKey, newKey string) Option {
+	return func(c *loadConfig) {
+		c.aliases = append(c.aliases, [2]string{oldKey, newKey})
+	}
+}
+
+// aliases holds the deprecated keys of a configuration, see Alias.
+type aliases struct {
+	mu sync.RWMutex
+	// pinned aliases, see pinned, resolve keys without recording nor logging
+	// the reads of deprecated keys.
+	pinned bool
+	// renamed maps deprecated keys to their new names, and old the other way
+	// round, as several deprecated keys may share the same new name.
+	renamed map[string]string
+	old     map[string][]string
+	// sites records, by deprecated key, the call sites it was read from.
+	sites map[string]map[string]bool
+}
+
+// DeprecatedKey reports a deprecated key still in use, see DeprecatedKeys.
+type DeprecatedKey struct {
+	// Key is the deprecated key and NewKey the one replacing it.
+	Key    string
+	NewKey string
+	// Set tells whether the configuration still sets the deprecated key.
+	Set bool
+	// CallSites are the file:line locations the deprecated key was read from,
+	// directly or through its new name while only the deprecated key is set.
+	CallSites []string
+}
+
+// Alias registers oldKey as a deprecated name of newKey, for keys to be
+// renamed without every consumer and configuration file switching at the same
+// time. Reading either key returns the value of newKey when it is set, and the
+// value of oldKey otherwise.
+//
+// Reading the value of oldKey, whichever name is used, logs a warning naming
+// oldKey and the call site, once per deprecated key. DeprecatedKeys reports the
+// deprecated keys still in use.
+//
+// Keys of a view created by Sub are relative to the view. Aliases registered
+// once loaded don't apply to the schema checks of the load, see WithAlias.
+func (p *Config) Alias(oldKey, newKey string) {
+	oldKey, newKey = p.key(oldKey), p.key(newKey)
+	a := &p.base().aliases
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.renamed == nil {
+		a.renamed, a.old, a.sites = map[string]string{}, map[string][]string{}, map[string]map[string]bool{}
+	}
+
+	if _, ok := a.renamed[oldKey]; ok {
+		return
+	}
+
+	a.renamed[oldKey] = newKey
+	a.old[newKey] = append(a.old[newKey], oldKey)
+}
+
+// names returns the keys key is read from, in order of precedence: its new name
+// and its deprecated ones.
+func (a *aliases) names(key string) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if len(a.renamed) == 0 {
+		return nil
+	}
+
+	if newKey, ok := a.renamed[key]; ok {
+		key = newKey
+	}
+
+	if len(a.old[key]) == 0 {
+		return nil
+	}
+
+	return append([]string{key}, a.old[key]...)
+}
+
+// rawAlias retrieves from s the raw value of key, or of the first of its
+// aliases that is set, see Alias. It also returns the key the value comes
+// from.
+func (p *Config) rawAlias(s *snapshot, key string) (string, bool, string) {
+	for _, k := range p.base().aliases.names(key) {
+		if v, ok := p.rawKey(s, k); ok {
+			return v, true, k
+		}
+	}
+
+	v, ok := p.rawKey(s, key)
+
+	return v, ok, key
+}
+
+// deprecated records a read of key, made through a getter, when either key or
+// the key its value comes from is deprecated. The first read of each
+// deprecated key is logged.
+func (p *Config) deprecated(s *snapshot, key string) {
+	a := &p.base().aliases
+	if a.pinned || a.names(key) == nil {
+		return
+	}
+
+	old := key
+	if _, ok := a.renamedTo(key); !ok {
+		_, _, old = p.rawAlias(s, key)
+	}
+
+	newKey, ok := a.renamedTo(old)
+	if !ok {
+		return
+	}
+
+	site := callSite()
+
+	a.mu.Lock()
+	first := len(a.sites[old]) == 0
+	if a.sites[old] == nil {
+		a.sites[old] = map[string]bool{}
+	}
+	a.sites[old][site] = true
+	a.mu.Unlock()
+
+	if first {
+		p.base().getLogger().Warn("deprecated configuration key read",
+			log.String("key", old),
+			log.String("new_key", newKey),
+			log.String("caller", site),
+		)
+	}
+}
+
+// pin copies the deprecated keys of a to b, pinned.
+func (a *aliases) pin(b *aliases) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	b.pinned = true
+	b.renamed, b.old = make(map[string]string, len(a.renamed)), make(map[string][]string, len(a.old))
+
+	for k, v := range a.renamed {
+		b.renamed[k] = v
+	}
+
+	for k, v := range a.old {
+		b.old[k] = append([]string{}, v...)
+	}
+}
+
+// renamedTo returns the new name of key when key is deprecated, see Alias.
+func (p *Config) renamedTo(key string) (string, bool) {
+	newKey, ok := p.base().aliases.renamedTo(p.key(key))
+	if !ok || p.prefix == "" {
+		return newKey, ok
+	}
+
+	return strings.TrimPrefix(newKey, p.prefix), strings.HasPrefix(newKey, p.prefix)
+}
+
+func (a *aliases) renamedTo(key string) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	newKey, ok := a.renamed[key]
+
+	return newKey, ok
+}
+
+// DeprecatedKeys reports the deprecated keys, see Alias, that are still set in
+// the configuration or were read since it was loaded, sorted by key.
+func (p *Config) DeprecatedKeys() []DeprecatedKey {
+	a := &p.base().aliases
+	s := p.snapshot()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var keys []DeprecatedKey
+
+	for old, newKey := range a.renamed {
+		_, set := p.rawKey(s, old)
+		if !set && len(a.sites[old]) == 0 {
+			continue
+		}
+
+		k := DeprecatedKey{Key: old, NewKey: newKey, Set: set}
+		for site := range a.sites[old] {
+			k.CallSites = append(k.CallSites, site)
+		}
+
+		sort.Strings(k.CallSites)
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+
+	return keys
+}
+
+// callSite returns the file:line location of the first caller outside of this
+// package.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	for {
+		f, more := frames.Next()
+
+		// Methods promoted by embedding, as done by configtest, show up as
+		// autogenerated frames.
+		if !strings.HasPrefix(f.Function, _package+".") && f.File != "<autogenerated>" {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// getLogger returns the logger warnings are logged to.
+func (p *Config) getLogger() log.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+
+	return log.DefaultLogger
+}
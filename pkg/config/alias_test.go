@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAlias(t *testing.T) {
+	tt := []struct {
+		name     string
+		props    map[string]string
+		expected string
+	}{
+		{
+			name:     "only the new key is set",
+			props:    map[string]string{"db.pool.size": "20"},
+			expected: "20",
+		},
+		{
+			name:     "only the old key is set",
+			props:    map[string]string{"db.poolSize": "10"},
+			expected: "10",
+		},
+		{
+			name:     "the new key is preferred",
+			props:    map[string]string{"db.poolSize": "10", "db.pool.size": "20"},
+			expected: "20",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			cfg := LoadMap(tc.props)
+
+			// When
+			cfg.Alias("db.poolSize", "db.pool.size")
+
+			// Then
+			require.Equal(t, tc.expected, cfg.GetString("db.pool.size", ""))
+			require.Equal(t, tc.expected, cfg.GetString("db.poolSize", ""))
+			require.Equal(t, tc.expected, cfg.Sub("db").GetString("pool.size", ""))
+			require.True(t, cfg.Has("db.poolSize"))
+		})
+	}
+}
+
+func TestAlias_references(t *testing.T) {
+	// Given
+	m := map[string]string{
+		"db.hostname": "localhost",
+		"db.url":      "jdbc://${db.host}/x",
+	}
+
+	// When
+	cfg := LoadMap(m, WithAlias("db.hostname", "db.host"))
+
+	// Then
+	require.Equal(t, "jdbc://localhost/x", cfg.GetString("db.url", ""))
+}
+
+func TestAlias_warning(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.poolSize=10\nlegacy.timeout=5\ntimeout=6\n")
+
+	var buf bytes.Buffer
+	lvl := log.NewAtomicLevelAt(log.WarnLevel)
+	logger := log.NewProductionLogger(&lvl, log.WithWriter(zapcore.AddSync(&buf)), log.WithJSONEncoding())
+
+	cfg, err := LoadFile(filename, WithLogger(logger))
+	require.NoError(t, err)
+
+	cfg.Alias("db.poolSize", "db.pool.size")
+	cfg.Alias("legacy.timeout", "timeout")
+	cfg.Alias("unused", "used")
+
+	// When
+	require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+	require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+	require.Equal(t, 10, cfg.GetInt("db.poolSize", 0))
+	require.Equal(t, 6, cfg.GetInt("timeout", 0))
+
+	// Then
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"msg":"deprecated configuration key read"`)
+	require.Contains(t, lines[0], `"key":"db.poolSize"`)
+	require.Contains(t, lines[0], `"new_key":"db.pool.size"`)
+	require.Contains(t, lines[0], `"caller":"`)
+
+	keys := cfg.DeprecatedKeys()
+	require.Len(t, keys, 2)
+	require.Equal(t, "db.poolSize", keys[0].Key)
+	require.Equal(t, "db.pool.size", keys[0].NewKey)
+	require.True(t, keys[0].Set)
+	require.NotEmpty(t, keys[0].CallSites)
+	require.Equal(t, DeprecatedKey{Key: "legacy.timeout", NewKey: "timeout", Set: true}, keys[1])
+}
+
+func TestLoad_withAliasAndSchema(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.jdbcUrl=jdbc://localhost/x\ndb.poolSize=20\n")
+
+	var buf bytes.Buffer
+	lvl := log.NewAtomicLevelAt(log.WarnLevel)
+	logger := log.NewProductionLogger(&lvl, log.WithWriter(zapcore.AddSync(&buf)), log.WithJSONEncoding())
+
+	// When
+	cfg, err := LoadFile(filename,
+		WithSchema(newTestSchema(t)),
+		WithLogger(logger),
+		WithAlias("db.jdbcUrl", "db.url"),
+		WithAlias("db.poolSize", "db.pool.size"),
+	)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, buf.String())
+	require.Equal(t, "jdbc://localhost/x", cfg.GetString("db.url", ""))
+	require.Equal(t, 20, cfg.GetInt("db.pool.size", 0))
+	require.Len(t, cfg.DeprecatedKeys(), 2)
+
+	// When
+	writeConfig(t, filename, "db.jdbcUrl=jdbc://localhost/y\ndb.poolSize=many\n")
+	err = cfg.Reload()
+
+	// Then
+	require.EqualError(t, err, `checking configuration schema: key db.poolSize: malformed value "many": strconv.Atoi: parsing "many": invalid syntax`)
+
+	// When
+	writeConfig(t, filename, "db.jdbcUrl=jdbc://localhost/y\ndb.poolSize=30\n")
+	err = cfg.Reload()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 30, cfg.GetInt("db.pool.size", 0))
+}
+
+func TestSchema_Check_alias(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"db.jdbcUrl": "jdbc://localhost/x"}, WithAlias("db.jdbcUrl", "db.url"))
+
+	// Then
+	require.NoError(t, newTestSchema(t).Check(cfg))
+}
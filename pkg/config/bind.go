@@ -0,0 +1,223 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config/utils"
+	"go.uber.org/multierr"
+)
+
+const (
+	_configTag  = "config"
+	_defaultTag = "default"
+)
+
+var _durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind populates the struct pointed to by v with the properties named by the
+// "config" tag of its fields. When a property doesn't exist the value of the
+// "default" tag is used instead, if any, otherwise the field is left untouched.
+//
+//	type Settings struct {
+//		PoolSize int           `config:"db.pool.size" default:"10"`
+//		Timeout  time.Duration `config:"db.timeout" default:"1s"`
+//		Hosts    []string      `config:"db.hosts"`
+//		Car      Car           `config:"json.car.property"`
+//	}
+//
+// Fields can be of any type supported by the getters. Durations are parsed as
+// nanoseconds, like GetDuration does, or with time.ParseDuration otherwise.
+// Slices of strings, ints and floats are parsed as comma-separated values,
+// like GetStringSlice does. Any other type is unmarshalled from JSON, like
+// GetJSONPropertyAndUnmarshal does.
+//
+// Fields can also declare validation rules in their "validate" tag:
+//
+//	PoolSize int      `config:"db.pool.size" validate:"required,min=1,max=100"`
+//	Mode     string   `config:"db.mode" validate:"oneof=read write,pattern=^[a-z]+$"`
+//	Hosts    []string `config:"db.hosts" validate:"nonempty"`
+//
+// The available rules are required, which fails when neither the property nor
+// a default exist, min and max, which compare numbers and durations by value
+// and strings and slices by length, oneof, which takes a space-separated list
+// of accepted values, pattern, which takes a regular expression and must be the
+// last rule, and nonempty. The oneof and pattern rules are applied to every
+// element of slices.
+//
+// Unlike the getters, a value that can't be parsed is reported as an error.
+// Bind doesn't stop at the first error: every property that can't be parsed
+// or violates a rule is reported as a *KeyError, combined into a single error
+// whose individual errors can be retrieved with multierr.Errors. Values that
+// may disclose a secret are redacted from the errors, see ParseError.
+func (p *Config) Bind(v interface{}) error {
+	return bind(p.lookup, p.secret, v)
+}
+
+// bind populates the struct pointed to by v with the properties retrieved from
+// the given lookup function, which reports missing properties with ErrNotFound.
+// The values of the keys secret reports are redacted from the errors.
+func bind(lookup func(key string) (string, error), secret func(key string) bool, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("binding configuration: expected a non-nil pointer to a struct, got %T", v)
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var errs []error
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+
+		key, ok := f.Tag.Lookup(_configTag)
+		if !ok || key == "" || key == "-" {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			errs = append(errs, &KeyError{Key: key, Err: fmt.Errorf("field %s is unexported", f.Name)})
+			continue
+		}
+
+		raw, err := lookup(key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+
+		present := err == nil
+		if !present {
+			raw, present = f.Tag.Lookup(_defaultTag)
+		}
+
+		hidden := err == nil && secret(key)
+
+		if present {
+			if err := setField(rv.Field(i), raw); err != nil {
+				if hidden {
+					errs = append(errs, redacted(key, err))
+				} else {
+					errs = append(errs, malformed(key, raw, err))
+				}
+
+				continue
+			}
+		}
+
+		for _, err := range validateField(f, rv.Field(i), present) {
+			var v *violation
+			if hidden && errors.As(err, &v) {
+				v.got = _redacted
+			}
+
+			errs = append(errs, &KeyError{Key: key, Err: err})
+		}
+	}
+
+	return multierr.Combine(errs...)
+}
+
+// setField parses raw according to the type of field and stores the result.
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == _durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := parseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	case reflect.Slice:
+		return setSlice(field, raw)
+	default:
+		return json.Unmarshal([]byte(raw), field.Addr().Interface())
+	}
+
+	return nil
+}
+
+// setSlice parses raw as comma-separated values when field is a slice of
+// strings, ints or floats, and as JSON otherwise.
+func setSlice(field reflect.Value, raw string) error {
+	var convert func([]string) (interface{}, error)
+
+	switch field.Type().Elem() {
+	case reflect.TypeOf(""):
+		convert = func(v []string) (interface{}, error) { return v, nil }
+	case reflect.TypeOf(0):
+		convert = func(v []string) (interface{}, error) { return utils.ConvertStringArrayToIntArray(v) }
+	case reflect.TypeOf(float64(0)):
+		convert = func(v []string) (interface{}, error) { return utils.ConvertStringArrayToFloatArray(v) }
+	default:
+		return json.Unmarshal([]byte(raw), field.Addr().Interface())
+	}
+
+	if raw == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	list, err := utils.ConvertStringToList(raw)
+	if err != nil {
+		return err
+	}
+
+	values, err := convert(list)
+	if err != nil {
+		return err
+	}
+
+	field.Set(reflect.ValueOf(values).Convert(field.Type()))
+
+	return nil
+}
+
+// parseDuration parses v as nanoseconds or, failing that, with
+// time.ParseDuration.
+func parseDuration(v string) (time.Duration, error) {
+	if d, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(d), nil
+	}
+
+	return time.ParseDuration(v)
+}
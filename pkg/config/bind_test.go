@@ -0,0 +1,129 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type settings struct {
+	String         string        `config:"string"`
+	Bool           bool          `config:"bool"`
+	Int            int           `config:"int"`
+	Int8           int8          `config:"int"`
+	Uint           uint          `config:"int"`
+	Float          float64       `config:"float"`
+	Duration       time.Duration `config:"duration"`
+	ParsedDuration time.Duration `config:"format.duration"`
+	IntList        []int         `config:"int.list"`
+	FloatList      []float64     `config:"float.list"`
+	StringList     []string      `config:"string.list"`
+	Car            Car           `config:"json.car.property"`
+	CarPtr         *Car          `config:"json.car.property"`
+	DefaultInt     int           `config:"non-existent-value" default:"10"`
+	DefaultTimeout time.Duration `config:"non-existent-value" default:"1s"`
+	DefaultList    []string      `config:"non-existent-value" default:"a,b"`
+	Untouched      string        `config:"non-existent-value"`
+	Ignored        string
+}
+
+func TestBind(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	s := settings{Untouched: "preset", Ignored: "preset"}
+
+	// When
+	err = cfg.Bind(&s)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "value", s.String)
+	require.Equal(t, true, s.Bool)
+	require.Equal(t, 9, s.Int)
+	require.Equal(t, int8(9), s.Int8)
+	require.Equal(t, uint(9), s.Uint)
+	require.Equal(t, 9.12, s.Float)
+	require.Equal(t, time.Duration(91218), s.Duration)
+	require.Equal(t, time.Duration(91218), s.ParsedDuration)
+	require.Equal(t, []int{10, 15, 90}, s.IntList)
+	require.Equal(t, []float64{1.0, 1.5, 9.0}, s.FloatList)
+	require.Equal(t, []string{"a1,a2", "b1", "c1"}, s.StringList)
+	require.Equal(t, "Gol 1.6", s.Car.Model)
+	require.Equal(t, "Volkswagen", s.CarPtr.Maker.Name)
+	require.Equal(t, 10, s.DefaultInt)
+	require.Equal(t, time.Second, s.DefaultTimeout)
+	require.Equal(t, []string{"a", "b"}, s.DefaultList)
+	require.Equal(t, "preset", s.Untouched)
+	require.Equal(t, "preset", s.Ignored)
+}
+
+func TestBind_err(t *testing.T) {
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	tt := []struct {
+		name          string
+		target        interface{}
+		expectedError string
+	}{
+		{
+			name:          "not a pointer",
+			target:        settings{},
+			expectedError: "binding configuration: expected a non-nil pointer to a struct, got config.settings",
+		},
+		{
+			name:          "nil pointer",
+			target:        (*settings)(nil),
+			expectedError: "binding configuration: expected a non-nil pointer to a struct, got *config.settings",
+		},
+		{
+			name: "invalid int list",
+			target: &struct {
+				List []int `config:"int.invalid.list"`
+			}{},
+			expectedError: `key int.invalid.list: malformed value "10,15,a": strconv.Atoi: parsing "a": invalid syntax`,
+		},
+		{
+			name: "int overflow",
+			target: &struct {
+				Int int8 `config:"duration"`
+			}{},
+			expectedError: `key duration: malformed value "91218": strconv.ParseInt: parsing "91218": value out of range`,
+		},
+		{
+			name: "invalid json",
+			target: &struct {
+				Car Car `config:"string"`
+			}{},
+			expectedError: `key string: malformed value "value": invalid character 'v' looking for beginning of value`,
+		},
+		{
+			name: "invalid default",
+			target: &struct {
+				Timeout time.Duration `config:"non-existent-value" default:"soon"`
+			}{},
+			expectedError: `key non-existent-value: malformed value "soon": time: invalid duration "soon"`,
+		},
+		{
+			name: "unexported field",
+			target: &struct {
+				value string `config:"string"`
+			}{},
+			expectedError: "key string: field value is unexported",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.EqualError(t, cfg.Bind(tc.target), tc.expectedError)
+		})
+	}
+}
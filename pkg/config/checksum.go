@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// algorithm is a checksum algorithm a configuration file can be verified with.
+type algorithm struct {
+	name    string
+	newHash func() hash.Hash
+}
+
+// sidecar returns the name of the file holding the checksum of filename.
+func (a algorithm) sidecar(filename string) string {
+	return filename + "." + a.name
+}
+
+// sum returns the hex-encoded digest of b.
+func (a algorithm) sum(b []byte) string {
+	h := a.newHash()
+	h.Write(b) //nolint:errcheck // hash.Hash never returns an error.
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var (
+	_md5    = algorithm{name: "md5", newHash: md5.New}
+	_sha256 = algorithm{name: "sha256", newHash: sha256.New}
+	_sha512 = algorithm{name: "sha512", newHash: sha512.New}
+
+	// _algorithms lists the supported algorithms, strongest first. This is the
+	// order in which their checksum files are looked for.
+	_algorithms = []algorithm{_sha512, _sha256, _md5}
+)
+
+// WriteChecksumFile writes the checksum of the given configuration file next
+// to it, in a file named after the algorithm, "md5", "sha256" or "sha512", as
+// expected when loading it. As the strongest algorithm is looked for first,
+// checksum files of stronger algorithms must not be left next to it.
+func WriteChecksumFile(filename, algorithm string) error {
+	alg, ok := algorithmByName(algorithm)
+	if !ok {
+		return fmt.Errorf("unknown checksum algorithm %q", algorithm)
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(alg.sidecar(filename), []byte(alg.sum(b)), 0o644) //nolint:gosec
+}
+
+// algorithmByName returns the algorithm called name, ignoring case and dashes,
+// so that "SHA-256" and "sha256" are the same.
+func algorithmByName(name string) (algorithm, bool) {
+	name = strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	for _, a := range _algorithms {
+		if a.name == name {
+			return a, true
+		}
+	}
+
+	return algorithm{}, false
+}
+
+// verify checks b, the contents of filename, against the checksum stored next
+// to it in a .sha512, .sha256 or .md5 file, the first one found being used.
+//
+// The checksum file may contain the bare hex digest, the digest prefixed by
+// its algorithm ("sha256:<digest>"), or the output of the usual checksum tools
+// ("<digest>  filename" or "SHA256 (filename) = <digest>"). The algorithm named
+// in the file, if any, takes precedence over the file extension.
+//
+// It returns the name of the algorithm used, which is empty when checksums are
+// disabled, and the verified digest.
+func verify(b []byte, filename string) (string, string, error) {
+	if c := os.Getenv(_checksumEnabled); c == "false" {
+		return "", "", nil
+	}
+
+	if len(b) == 0 {
+		return "", "", fmt.Errorf("the file %s is empty", filename)
+	}
+
+	alg, sidecar, content, err := readChecksumFile(filename)
+	if err != nil {
+		return "", "", err
+	}
+
+	alg, expected, err := parseChecksum(content, alg, filepath.Base(filename))
+	if err != nil {
+		return "", "", fmt.Errorf("parsing %s: %v", sidecar, err)
+	}
+
+	if err := checkSum(b, filename, alg, expected); err != nil {
+		return "", "", err
+	}
+
+	return alg.name, expected, nil
+}
+
+// checkSum checks b, the contents of name, against the expected digest.
+func checkSum(b []byte, name string, alg algorithm, expected string) error {
+	if actual := alg.sum(b); actual != expected {
+		return fmt.Errorf("%s checksum mismatch for %s: expected %s, got %s", alg.name, name, expected, actual)
+	}
+
+	return nil
+}
+
+// readChecksumFile reads the first checksum file found for filename. When none
+// exists, the error of reading the .md5 one is returned.
+func readChecksumFile(filename string) (algorithm, string, []byte, error) {
+	alg := _md5
+
+	for _, a := range _algorithms {
+		if _, err := os.Stat(a.sidecar(filename)); err == nil {
+			alg = a
+			break
+		}
+	}
+
+	sidecar := alg.sidecar(filename)
+
+	content, err := ioutil.ReadFile(sidecar)
+	if err != nil {
+		return alg, sidecar, nil, err
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return alg, sidecar, nil, fmt.Errorf("the file %s is empty", sidecar)
+	}
+
+	return alg, sidecar, content, nil
+}
+
+// parseChecksum extracts the digest of the file called base from the contents
+// of a checksum file. It returns the algorithm to use, which is alg unless the
+// contents name another one, and the lower-cased hex digest.
+func parseChecksum(content []byte, alg algorithm, base string) (algorithm, string, error) {
+	var (
+		found  bool
+		digest string
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, d, file := parseChecksumLine(line)
+
+		// Checksum files may list several files, prefer the line that names
+		// the verified file.
+		if !found || file == base {
+			if name != "" {
+				a, ok := algorithmByName(name)
+				if !ok {
+					return alg, "", fmt.Errorf("unsupported checksum algorithm %q", name)
+				}
+
+				alg = a
+			}
+
+			digest, found = strings.ToLower(d), true
+		}
+
+		if file == base {
+			break
+		}
+	}
+
+	if !found {
+		return alg, "", fmt.Errorf("no checksum found")
+	}
+
+	if _, err := hex.DecodeString(digest); err != nil || len(digest) != alg.newHash().Size()*2 {
+		return alg, "", fmt.Errorf("malformed %s checksum %q", alg.name, digest)
+	}
+
+	return alg, digest, nil
+}
+
+// parseChecksumLine parses a single line of a checksum file, returning the
+// algorithm name and file name when present.
+func parseChecksumLine(line string) (name, digest, file string) {
+	// BSD style: "SHA256 (filename) = digest".
+	if i := strings.Index(line, " ("); i > 0 {
+		if j := strings.LastIndex(line, ") = "); j > i {
+			return line[:i], strings.TrimSpace(line[j+4:]), filepath.Base(line[i+2 : j])
+		}
+	}
+
+	// Prefixed digest: "sha256:digest".
+	if i := strings.IndexByte(line, ':'); i > 0 && !strings.ContainsAny(line[:i], " \t") {
+		name, line = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	// GNU style: "digest  filename" or "digest *filename" in binary mode.
+	fields := strings.Fields(line)
+	if len(fields) > 1 {
+		file = strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		file = filepath.Base(file)
+	}
+
+	if len(fields) > 0 {
+		digest = fields[0]
+	}
+
+	return name, digest, file
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_sha256(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/sha256.properties")
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	cfg, err := Load()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+}
+
+func TestWriteChecksumFile(t *testing.T) {
+	for _, alg := range []string{"md5", "SHA-256", "sha512"} {
+		t.Run(alg, func(t *testing.T) {
+			// Given
+			filename := filepath.Join(t.TempDir(), "application.properties")
+			require.NoError(t, ioutil.WriteFile(filename, []byte("string=value\n"), 0o600))
+			t.Setenv("checksumEnabled", "true")
+
+			// When
+			err := WriteChecksumFile(filename, alg)
+
+			// Then
+			require.NoError(t, err)
+
+			cfg, err := LoadFile(filename)
+			require.NoError(t, err)
+			require.Equal(t, "value", cfg.GetString("string", ""))
+		})
+	}
+}
+
+func TestWriteChecksumFile_err(t *testing.T) {
+	require.EqualError(t, WriteChecksumFile("testdata/valid.properties", "crc32"), `unknown checksum algorithm "crc32"`)
+}
+
+func TestVerify(t *testing.T) {
+	content := []byte("string=value\n")
+	md5Sum := md5.Sum(content) //nolint:gosec
+	sha256Sum := sha256.Sum256(content)
+	sha512Sum := sha512.Sum512(content)
+
+	md5Hex := hex.EncodeToString(md5Sum[:])
+	sha256Hex := hex.EncodeToString(sha256Sum[:])
+	sha512Hex := hex.EncodeToString(sha512Sum[:])
+
+	tt := []struct {
+		name          string
+		sidecars      map[string]string
+		expectedError string
+	}{
+		{
+			name:     "md5 bare digest",
+			sidecars: map[string]string{"md5": md5Hex},
+		},
+		{
+			name:     "md5 with trailing newline and upper case",
+			sidecars: map[string]string{"md5": strings.ToUpper(md5Hex) + "\n"},
+		},
+		{
+			name:     "md5sum output",
+			sidecars: map[string]string{"md5": md5Hex + "  application.properties\n"},
+		},
+		{
+			name:     "sha256sum binary mode output",
+			sidecars: map[string]string{"sha256": sha256Hex + " *application.properties\n"},
+		},
+		{
+			name: "sha256sum output listing several files",
+			sidecars: map[string]string{"sha256": strings.Repeat("0", 64) + "  other.properties\n" +
+				sha256Hex + "  /configs/application.properties\n"},
+		},
+		{
+			name:     "bsd style output",
+			sidecars: map[string]string{"sha512": "SHA512 (application.properties) = " + sha512Hex + "\n"},
+		},
+		{
+			name:     "algorithm prefixed digest",
+			sidecars: map[string]string{"md5": "sha-256:" + sha256Hex},
+		},
+		{
+			name:     "strongest checksum file wins",
+			sidecars: map[string]string{"md5": "invalid", "sha256": sha256Hex},
+		},
+		{
+			name:          "sha256 mismatch",
+			sidecars:      map[string]string{"sha256": strings.Repeat("a", 64)},
+			expectedError: "sha256 checksum mismatch for %s: expected " + strings.Repeat("a", 64) + ", got " + sha256Hex,
+		},
+		{
+			name:          "empty checksum file",
+			sidecars:      map[string]string{"sha512": " \n"},
+			expectedError: "the file %s.sha512 is empty",
+		},
+		{
+			name:          "malformed digest",
+			sidecars:      map[string]string{"sha256": md5Hex},
+			expectedError: "parsing %s.sha256: malformed sha256 checksum \"" + md5Hex + "\"",
+		},
+		{
+			name:          "unsupported algorithm",
+			sidecars:      map[string]string{"md5": "crc32:abcd"},
+			expectedError: "parsing %s.md5: unsupported checksum algorithm \"crc32\"",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			t.Setenv("checksumEnabled", "true")
+			filename := filepath.Join(t.TempDir(), "application.properties")
+			for ext, sidecar := range tc.sidecars {
+				require.NoError(t, ioutil.WriteFile(filename+"."+ext, []byte(sidecar), 0o600))
+			}
+
+			// When
+			_, _, err := verify(content, filename)
+
+			// Then
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, strings.ReplaceAll(tc.expectedError, "%s", filename))
+		})
+	}
+}
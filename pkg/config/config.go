@@ -1,16 +1,21 @@
 package config
 
 import (
-	"bytes"
-	"crypto/md5" //nolint:gosec
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/factory-roraimabits/go-deer/pkg/config/utils"
+	"github.com/factory-roraimabits/go-deer/pkg/log"
 	"github.com/magiconair/properties"
 )
 
@@ -18,128 +23,389 @@ const (
 	_defaultConfigPath      = "/configs/latest/application.properties"
 	_propertyConfigFileName = "configFileName"
 	_checksumEnabled        = "checksumEnabled"
+
+	// _mapSource is the source reported for the properties of a configuration
+	// created with LoadMap.
+	_mapSource = "map"
+
+	// _readAttempts is how many times a file is read when it or its checksum
+	// files changed while it was read, and _readRetryDelay how long to wait
+	// for them to be all written before checking.
+	_readAttempts   = 3
+	_readRetryDelay = 10 * time.Millisecond
 )
 
 // Config provides all configurations loaded from the fury's configuration.
 type Config struct {
-	prop     *properties.Properties
+	state    atomic.Value // *snapshot
 	filename string
+	layers   []layer
+	env      *envOverrides
+	trusted  []TrustedKey
+
+	encryptionKey []byte
+	keepEncrypted bool
+	schema        *Schema
+	logger        log.Logger
+	aliases       aliases
+	tracker       *tracker
+
+	// root is the configuration a view created by Sub reads from, prefix the
+	// prefix of the keys the view exposes.
+	root   *Config
+	prefix string
+
+	mu        sync.Mutex
+	stamp     string
+	listeners []ChangeFunc
+	// changes are the changes the OnChange callbacks weren't called with yet,
+	// and notifying tells whether a goroutine is calling them, see notify.
+	changes   []change
+	notifying bool
+	// edits are the modifications made by Set and Delete, not saved yet.
+	edits []edit
 }
 
-// Load loads the configurations.
-func Load() (*Config, error) {
+type loadConfig struct {
+	env           *envOverrides
+	scopes        []string
+	dirs          []string
+	trusted       []TrustedKey
+	encryptionKey []byte
+	keepEncrypted bool
+	client        *http.Client
+	cacheDir      *string
+	schema        *Schema
+	logger        log.Logger
+	tracking      bool
+	aliases       [][2]string
+	unsignedDirs  bool
+}
+
+// Option configures how a Config is loaded.
+type Option func(c *loadConfig)
+
+// Load loads the configurations from the file named by the configFileName
+// environment variable, or from /configs/latest/application.properties.
+//
+// The configuration may also be served over HTTP, configFileName being then
+// an http:// or https:// URL. Its checksum is read from the X-Config-Checksum
+// response header and its signature, if required, from the X-Config-Signature
+// header. Reloads send the ETag of the last response so that unchanged
+// configurations are not transferred again. The last valid configuration is
+// cached on disk when a cache directory is configured, see WithCacheDir, and
+// used when the server can't be reached at load time. Scopes don't apply to
+// such configurations.
+func Load(opts ...Option) (*Config, error) {
 	if c := os.Getenv(_propertyConfigFileName); c != "" {
-		return load(c)
+		return LoadFile(c, opts...)
 	}
 
-	return load(_defaultConfigPath)
+	return LoadFile(_defaultConfigPath, opts...)
 }
 
-func load(filename string) (*Config, error) {
-	b, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("reading configuration: %v", err)
+// LoadFile loads the configurations from the given file, as Load does.
+func LoadFile(filename string, opts ...Option) (*Config, error) {
+	var cfg loadConfig
+	for _, opt := range opts {
+		opt(&cfg)
 	}
 
-	if err = verify(b, filename); err != nil {
-		return nil, fmt.Errorf("verifying configuration: %v", err)
+	if cfg.scopes == nil {
+		cfg.scopes = scopesFromEnv()
 	}
 
-	prop, err := properties.Load(b, properties.UTF8)
-	if err != nil {
-		return nil, fmt.Errorf("loading configuration: %v", err)
+	if cfg.dirs == nil {
+		cfg.dirs = dirsFromEnv()
 	}
 
-	return &Config{
-		prop:     prop,
-		filename: filename,
-	}, nil
+	if cfg.trusted == nil {
+		keys, err := trustedKeysFromEnv()
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.trusted = keys
+	}
+
+	if len(cfg.trusted) > 0 && len(cfg.dirs) > 0 && !cfg.unsignedDirs {
+		return nil, fmt.Errorf("loading configuration: directories %s can't be signed, see WithUnsignedDirs",
+			strings.Join(cfg.dirs, ", "))
+	}
+
+	if cfg.encryptionKey == nil {
+		key, err := encryptionKeyFromEnv()
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.encryptionKey = key
+	}
+
+	if cfg.cacheDir == nil {
+		dir := os.Getenv(_configCacheDir)
+		cfg.cacheDir = &dir
+	}
+
+	return load(filename, cfg)
 }
 
-func verify(b []byte, filename string) error {
-	if c := os.Getenv(_checksumEnabled); c == "false" {
-		return nil
+// LoadMap creates a configuration holding the given properties. As no file is
+// involved the configuration is neither verified nor reloadable. It is mostly
+// useful in tests, see the configtest package.
+//
+// Only the WithAccessTracking, WithAlias and WithLogger options apply, the
+// others being about files. As loading the same properties from a file would
+// fail, LoadMap panics when a reference can't be resolved or is circular.
+func LoadMap(m map[string]string, opts ...Option) *Config {
+	var cfg loadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := &snapshot{
+		prop:    loadMap(m),
+		sources: map[string]string{},
+		loaded:  time.Now(),
+	}
+
+	for k := range m {
+		s.sources[k] = _mapSource
+	}
+
+	c := &Config{logger: cfg.logger}
+	if cfg.tracking {
+		c.tracker = newTracker()
+	}
+
+	for _, a := range cfg.aliases {
+		c.Alias(a[0], a[1])
+	}
+
+	if err := c.checkReferences(s); err != nil {
+		panic(err)
+	}
+
+	c.state.Store(s)
+
+	return c
+}
+
+func load(filename string, cfg loadConfig) (*Config, error) {
+	c := &Config{
+		filename: filename,
+		layers:   newLayers(filename, cfg.scopes, cfg.dirs),
+		env:      cfg.env,
+		trusted:  cfg.trusted,
+
+		encryptionKey: cfg.encryptionKey,
+		keepEncrypted: cfg.keepEncrypted,
+		schema:        cfg.schema,
+		logger:        cfg.logger,
+	}
+
+	if cfg.tracking {
+		c.tracker = newTracker()
 	}
 
-	if len(b) == 0 {
-		return fmt.Errorf("the file %s is empty", filename)
+	for _, a := range cfg.aliases {
+		c.Alias(a[0], a[1])
 	}
 
-	filenameMD5 := filename + ".md5"
+	if isURL(filename) {
+		r, err := newRemote(filename, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		c.layers[0].remote = r
+	}
 
-	expectedMD5, err := ioutil.ReadFile(filenameMD5)
+	c.stamp = c.fingerprint()
+
+	s, err := c.readLayers()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(expectedMD5) == 0 {
-		return fmt.Errorf("the file %s is empty", filenameMD5)
+	c.state.Store(s)
+
+	return c, nil
+}
+
+// read reads, verifies and parses the given configuration file, according to
+// its format, see formatFor. As the file and its checksum files are written one
+// after the other, by Save for instance, it is read again when it failed and
+// any of them changed meanwhile.
+func (p *Config) read(filename string) (*properties.Properties, layerState, error) {
+	for attempt := 1; ; attempt++ {
+		stamp := stampFiles(checkedFiles(filename))
+
+		prop, state, err := p.readFile(filename)
+		if err == nil || attempt == _readAttempts {
+			return prop, state, err
+		}
+
+		time.Sleep(_readRetryDelay)
+
+		if stampFiles(checkedFiles(filename)) == stamp {
+			return prop, state, err
+		}
 	}
+}
+
+// readFile reads, verifies and parses the given configuration file once.
+func (p *Config) readFile(filename string) (*properties.Properties, layerState, error) {
+	state := layerState{filename: filename}
 
-	currentMD5, err := md5FromBytes(b)
+	b, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return err
+		return nil, state, fmt.Errorf("reading configuration: %v", err)
 	}
 
-	if !bytes.Equal(currentMD5, expectedMD5) {
-		return fmt.Errorf("different md5 contents")
+	if state.checksum, state.sum, err = verify(b, filename); err != nil {
+		return nil, state, fmt.Errorf("verifying configuration: %v", err)
 	}
 
-	return nil
-}
+	if len(p.trusted) > 0 {
+		if state.signer, err = verifySignature(b, filename, p.trusted); err != nil {
+			return nil, state, fmt.Errorf("verifying configuration signature: %v", err)
+		}
+	}
 
-func md5FromBytes(b []byte) ([]byte, error) {
-	hash := md5.New() //nolint:gosec
-	if _, err := hash.Write(b); err != nil {
-		return nil, err
+	prop, err := formatFor(filename)(b)
+	if err != nil {
+		return nil, state, fmt.Errorf("loading configuration: %v", err)
 	}
 
-	var buf bytes.Buffer
-	if _, err := hex.NewEncoder(&buf).Write(hash.Sum(nil)); err != nil {
-		return nil, err
+	return prop, state, nil
+}
+
+// snapshot holds the properties resulting from merging every layer, along
+// with the layer that supplied each of them.
+type snapshot struct {
+	prop    *properties.Properties
+	sources map[string]string
+	layers  []layerState
+	// loaded is when the layers were read.
+	loaded time.Time
+}
+
+// layerState describes how a layer was verified when read.
+type layerState struct {
+	filename string
+	// checksum is the algorithm the file was verified with, empty when
+	// checksums are disabled, and sum the verified digest.
+	checksum string
+	sum      string
+	// signer is the ID of the key that signed the file, empty when signatures
+	// are not verified.
+	signer string
+}
+
+// snapshot returns the snapshot currently in use. The returned value must
+// never be modified as it may be shared with concurrent readers.
+func (p *Config) snapshot() *snapshot {
+	return p.base().state.Load().(*snapshot)
+}
+
+// props returns the properties currently in use. The returned value
+// must never be modified as it may be shared with concurrent readers.
+func (p *Config) props() *properties.Properties {
+	return p.snapshot().prop
+}
+
+// raw retrieves from s the raw value of the property, or of its aliases, see
+// Alias.
+func (p *Config) raw(s *snapshot, key string) (string, bool) {
+	v, ok, _ := p.rawAlias(s, key)
+	return v, ok
+}
+
+// rawKey retrieves from s the raw value of the property, giving precedence to
+// its environment variable override when enabled.
+func (p *Config) rawKey(s *snapshot, key string) (string, bool) {
+	if v, ok := p.lookupEnv(key); ok {
+		return v, true
 	}
 
-	return buf.Bytes(), nil
+	return s.prop.Get(key)
+}
+
+// Raw retrieves the property as written, without interpolating its references
+// nor decrypting it. Environment variable overrides are still applied.
+func (p *Config) Raw(key string) (string, bool) {
+	return p.raw(p.snapshot(), p.key(key))
 }
 
 // GetBool retrieve the property as bool value
 func (p *Config) GetBool(key string, value bool) bool {
-	return p.prop.GetBool(key, value)
+	if v, err := p.LookupBool(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
 // GetString retrieve the property as string value
 func (p *Config) GetString(key string, value string) string {
-	return p.prop.GetString(key, value)
+	if v, err := p.LookupString(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
 // GetInt retrieve the property as int value
 func (p *Config) GetInt(key string, value int) int {
-	return p.prop.GetInt(key, value)
+	if v, err := p.LookupInt(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
 // GetFloat64 retrieve the property as float value
 func (p *Config) GetFloat64(key string, value float64) float64 {
-	return p.prop.GetFloat64(key, value)
+	if v, err := p.LookupFloat64(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
 // GetUint retrieve the property as uint value
 func (p *Config) GetUint(key string, value uint) uint {
-	return p.prop.GetUint(key, value)
+	if v, err := p.LookupUint(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
 // GetDuration retrieve the property as duration value
 func (p *Config) GetDuration(key string, value time.Duration) time.Duration {
-	return p.prop.GetDuration(key, value)
+	if v, err := p.LookupDuration(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
-// GetAll retrieve all properties
+// GetAll retrieve all properties. Environment variable overrides are applied to
+// the keys present in the configuration, and encrypted values are decrypted.
 func (p *Config) GetAll() map[string]string {
-	return p.prop.Map()
+	m := map[string]string{}
+	for _, k := range p.Keys() {
+		m[k] = p.value(k)
+	}
+
+	return m
 }
 
 // GetStringSlice retrieve the property as string list values
 func (p *Config) GetStringSlice(key string, defaultValues []string) []string {
-	if v, exist := p.getList(key); exist {
+	if v, err := p.LookupStringSlice(key); err == nil {
 		return v
 	}
 
@@ -148,10 +414,8 @@ func (p *Config) GetStringSlice(key string, defaultValues []string) []string {
 
 // GetIntSlice retrieve the property as int list values
 func (p *Config) GetIntSlice(key string, defaultValues []int) []int {
-	if v, exist := p.getList(key); exist {
-		if result, err := utils.ConvertStringArrayToIntArray(v); err == nil {
-			return result
-		}
+	if v, err := p.LookupIntSlice(key); err == nil {
+		return v
 	}
 
 	return defaultValues
@@ -159,10 +423,8 @@ func (p *Config) GetIntSlice(key string, defaultValues []int) []int {
 
 // GetFloatSlice retrieve the property as float list values
 func (p *Config) GetFloatSlice(key string, defaultValues []float64) []float64 {
-	if v, exist := p.getList(key); exist {
-		if result, err := utils.ConvertStringArrayToFloatArray(v); err == nil {
-			return result
-		}
+	if v, err := p.LookupFloatSlice(key); err == nil {
+		return v
 	}
 
 	return defaultValues
@@ -170,28 +432,105 @@ func (p *Config) GetFloatSlice(key string, defaultValues []float64) []float64 {
 
 // GetParsedDuration retrieve the property as duration parsed with time.ParseDuration()
 func (p *Config) GetParsedDuration(key string, value time.Duration) time.Duration {
-	return p.prop.GetParsedDuration(key, value)
+	if v, err := p.LookupParsedDuration(key); err == nil {
+		return v
+	}
+
+	return value
 }
 
-// getList retrieve the property as list values
-func (p *Config) getList(key string) (values []string, exist bool) {
-	in, exist := p.prop.Get(key)
-	v, err := utils.ConvertStringToList(in)
+// GetByteSize retrieve the property as a number of bytes, such as "10MB"
+func (p *Config) GetByteSize(key string, value int64) int64 {
+	if v, err := p.LookupByteSize(key); err == nil {
+		return v
+	}
 
-	if err != nil {
-		return nil, false
+	return value
+}
+
+// GetURL retrieve the property as an absolute URL
+func (p *Config) GetURL(key string, value *url.URL) *url.URL {
+	if v, err := p.LookupURL(key); err == nil {
+		return v
+	}
+
+	return value
+}
+
+// GetIP retrieve the property as an IP address
+func (p *Config) GetIP(key string, value net.IP) net.IP {
+	if v, err := p.LookupIP(key); err == nil {
+		return v
 	}
 
-	return v, exist
+	return value
+}
+
+// GetCIDR retrieve the property as a network in CIDR notation
+func (p *Config) GetCIDR(key string, value *net.IPNet) *net.IPNet {
+	if v, err := p.LookupCIDR(key); err == nil {
+		return v
+	}
+
+	return value
+}
+
+// GetRegexp retrieve the property as a compiled regular expression
+func (p *Config) GetRegexp(key string, value *regexp.Regexp) *regexp.Regexp {
+	if v, err := p.LookupRegexp(key); err == nil {
+		return v
+	}
+
+	return value
+}
+
+// GetTime retrieve the property as an RFC 3339 time
+func (p *Config) GetTime(key string, value time.Time) time.Time {
+	if v, err := p.LookupTime(key); err == nil {
+		return v
+	}
+
+	return value
+}
+
+// GetBoolSlice retrieve the property as bool list values
+func (p *Config) GetBoolSlice(key string, defaultValues []bool) []bool {
+	if v, err := p.LookupBoolSlice(key); err == nil {
+		return v
+	}
+
+	return defaultValues
+}
+
+// GetDurationSlice retrieve the property as duration list values
+func (p *Config) GetDurationSlice(key string, defaultValues []time.Duration) []time.Duration {
+	if v, err := p.LookupDurationSlice(key); err == nil {
+		return v
+	}
+
+	return defaultValues
+}
+
+// GetStringMap retrieve the property as a map of "a=1,b=2" pairs
+func (p *Config) GetStringMap(key string, defaultValues map[string]string) map[string]string {
+	if v, err := p.LookupStringMap(key); err == nil {
+		return v
+	}
+
+	return defaultValues
 }
 
 // GetJSONPropertyAndUnmarshal Retrieve json property and unmarshal
 func (p *Config) GetJSONPropertyAndUnmarshal(key string, structType interface{}) error {
-	in, exist := p.prop.Get(key)
+	in, err := p.lookup(key)
 
-	if !exist {
+	if errors.Is(err, ErrNotFound) {
 		return fmt.Errorf("key %s nonexistent ", key)
 	}
 
+	if err != nil {
+		return err
+	}
+
 	return json.Unmarshal([]byte(in), structType)
 }
@@ -121,7 +121,7 @@ func TestLoad_err(t *testing.T) {
 		{
 			name:          "invalid md5",
 			filename:      "testdata/invalid-md5.properties",
-			expectedError: "verifying configuration: different md5 contents",
+			expectedError: "verifying configuration: md5 checksum mismatch for testdata/invalid-md5.properties: expected 902d7bef0294398c5ccdb11431c4ddc3, got 2597de5665a54f814c30ed27f6036270",
 		},
 		{
 			name:          "default config",
@@ -196,3 +196,23 @@ type Address struct {
 	Number       int
 	Neighborhood string
 }
+
+func TestLoadMap(t *testing.T) {
+	// When
+	cfg := LoadMap(map[string]string{
+		"string":   "value",
+		"int.list": "10,15,90",
+	})
+
+	// Then
+	require.Equal(t, "value", cfg.GetString("string", ""))
+	require.Equal(t, []int{10, 15, 90}, cfg.GetIntSlice("int.list", nil))
+	require.Equal(t, map[string]string{"string": "value", "int.list": "10,15,90"}, cfg.GetAll())
+
+	source, ok := cfg.Source("string")
+	require.True(t, ok)
+	require.Equal(t, "map", source)
+
+	require.EqualError(t, cfg.Reload(), "reloading configuration: not loaded from a file")
+	require.Equal(t, "value", cfg.GetString("string", ""))
+}
@@ -2,10 +2,14 @@ package configtest
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/factory-roraimabits/go-deer/pkg/config"
 	"github.com/factory-roraimabits/go-deer/pkg/config/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -121,3 +125,274 @@ func assertExistingValues(t *testing.T, c *Config) {
 	}
 	require.Equal(t, expectedProperties, c.GetAll())
 }
+
+func TestBind(t *testing.T) {
+	type settings struct {
+		String      string        `config:"string"`
+		Bool        bool          `config:"bool"`
+		Int         int           `config:"int"`
+		Float       float64       `config:"float"`
+		Duration    time.Duration `config:"duration"`
+		StringSlice []string      `config:"stringSliceKey"`
+		IntSlice    []int         `config:"intSliceKey"`
+		FloatSlice  []float64     `config:"floatSliceKey"`
+		Person      Person        `config:"jsonKey"`
+		Default     int           `config:"invalid" default:"18"`
+	}
+
+	var s settings
+	err := Load(newPropsMap()).Bind(&s)
+
+	require.NoError(t, err)
+	require.Equal(t, settings{
+		String:      _stringValue,
+		Bool:        _boolValue,
+		Int:         _intValue,
+		Float:       _floatValue,
+		Duration:    time.Duration(_durationValue),
+		StringSlice: _stringSliceValue,
+		IntSlice:    _intSliceValue,
+		FloatSlice:  _floatSliceValue,
+		Person:      _jsonValue,
+		Default:     _defInt,
+	}, s)
+}
+
+func TestInterpolation(t *testing.T) {
+	cfg := Load(map[string]string{
+		"db.host": "localhost",
+		"db.url":  "jdbc://${db.host}:${db.port:-5432}/x",
+	})
+
+	require.Equal(t, "jdbc://localhost:5432/x", cfg.GetString("db.url", ""))
+
+	raw, ok := cfg.Raw("db.url")
+	require.True(t, ok)
+	require.Equal(t, "jdbc://${db.host}:${db.port:-5432}/x", raw)
+}
+
+func TestRicherGetters(t *testing.T) {
+	c := Load(map[string]string{
+		"size":          "512KiB",
+		"endpoint":      "http://localhost:8080",
+		"ip":            "127.0.0.1",
+		"network":       "10.0.0.0/8",
+		"pattern":       "^a+$",
+		"time":          "2021-06-01T12:30:00Z",
+		"bool.list":     "true,false",
+		"duration.list": "1s,2m",
+		"map":           "a=1,b=2",
+		_invalidKey:     "(not valid",
+	})
+
+	defURL, _ := url.Parse("http://default")
+	defIP := net.IPv4(1, 1, 1, 1)
+	_, defNetwork, _ := net.ParseCIDR("172.16.0.0/12")
+	defPattern := regexp.MustCompile("^b+$")
+	defTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	defBools := []bool{false}
+	defDurations := []time.Duration{time.Hour}
+	defMap := map[string]string{"default": "true"}
+
+	require.Equal(t, int64(512<<10), c.GetByteSize("size", 1))
+	require.Equal(t, "localhost:8080", c.GetURL("endpoint", defURL).Host)
+	require.Equal(t, "127.0.0.1", c.GetIP("ip", defIP).String())
+	require.Equal(t, "10.0.0.0/8", c.GetCIDR("network", defNetwork).String())
+	require.True(t, c.GetRegexp("pattern", defPattern).MatchString("aaa"))
+	require.Equal(t, time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC), c.GetTime("time", defTime).UTC())
+	require.Equal(t, []bool{true, false}, c.GetBoolSlice("bool.list", defBools))
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Minute}, c.GetDurationSlice("duration.list", defDurations))
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, c.GetStringMap("map", defMap))
+
+	for _, key := range []string{_invalidKey, "non-existent"} {
+		require.Equal(t, int64(1), c.GetByteSize(key, 1))
+		require.Same(t, defURL, c.GetURL(key, defURL))
+		require.Equal(t, defIP, c.GetIP(key, defIP))
+		require.Same(t, defNetwork, c.GetCIDR(key, defNetwork))
+		require.Same(t, defPattern, c.GetRegexp(key, defPattern))
+		require.Equal(t, defTime, c.GetTime(key, defTime))
+		require.Equal(t, defDurations, c.GetDurationSlice(key, defDurations))
+		require.Equal(t, defMap, c.GetStringMap(key, defMap))
+	}
+
+	require.Equal(t, defBools, c.GetBoolSlice("non-existent", defBools))
+}
+
+func TestAlias(t *testing.T) {
+	// Given
+	c := Load(map[string]string{"db.poolSize": "10"})
+	c.Alias("db.poolSize", "db.pool.size")
+
+	// When
+	v := c.GetInt("db.pool.size", 0)
+
+	// Then
+	require.Equal(t, 10, v)
+
+	keys := c.DeprecatedKeys()
+	require.Len(t, keys, 1)
+	require.Len(t, keys[0].CallSites, 1)
+	require.Regexp(t, `/configtest_test\.go:\d+$`, keys[0].CallSites[0])
+}
+
+func TestLoad_overrides(t *testing.T) {
+	c := Load(map[string]string{"a": "1", "b": "2"}, map[string]string{"b": "20"}, map[string]string{"c": "30"})
+
+	require.Equal(t, map[string]string{"a": "1", "b": "20", "c": "30"}, c.GetAll())
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("checksumEnabled", "true")
+
+	tt := []struct {
+		name     string
+		new      func(t *testing.T) *Config
+		expected map[string]string
+	}{
+		{
+			name: "from a map",
+			new: func(t *testing.T) *Config {
+				return New(t, map[string]string{"a": "1", "b": " spaced\\value"}, map[string]string{"a": "10"})
+			},
+			expected: map[string]string{"a": "10", "b": " spaced\\value"},
+		},
+		{
+			name: "from a string",
+			new: func(t *testing.T) *Config {
+				return NewFromString(t, "# comment\na=1\nb=${a}", map[string]string{"c": "3"})
+			},
+			expected: map[string]string{"a": "1", "b": "1", "c": "3"},
+		},
+		{
+			name: "from a file",
+			new: func(t *testing.T) *Config {
+				return NewFromFile(t, "testdata/application.properties", map[string]string{"int": "20"})
+			},
+			expected: map[string]string{"string": "value", "int": "20"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			c := tc.new(t)
+
+			// Then
+			require.Equal(t, tc.expected, c.GetAll())
+		})
+	}
+}
+
+func TestConfig_SetAndSimulateReload(t *testing.T) {
+	// Given
+	c := New(t, map[string]string{"a": "1", "b": "2"})
+
+	var changes []map[string]string
+	c.OnChange(func(_, newValues map[string]string) {
+		changes = append(changes, newValues)
+	})
+
+	// When
+	c.Set("c", "3")
+	c.Delete("b")
+	c.SimulateReload(map[string]string{"a": "10", "b": "20"})
+
+	// Then
+	require.Equal(t, map[string]string{"a": "10", "c": "3"}, c.GetAll())
+	require.Equal(t, []map[string]string{
+		{"a": "1", "b": "2", "c": "3"},
+		{"a": "1", "c": "3"},
+		{"a": "10", "c": "3"},
+	}, changes)
+}
+
+// fakeT records the failure of a test helper instead of stopping the test.
+type fakeT struct {
+	testing.TB
+	failure string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Fatal(args ...interface{}) {
+	f.failure = fmt.Sprint(args...)
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.failure = fmt.Sprintf(format, args...)
+}
+
+func TestConfig_Set_err(t *testing.T) {
+	// Given
+	c := New(t, map[string]string{"a": "1", "b": "${a}"})
+
+	ft := &fakeT{TB: t}
+	c.t = ft
+
+	// When
+	c.Delete("a")
+
+	// Then
+	require.Equal(t, "interpolating configuration: key b: interpolating value: unresolved reference ${a}", ft.failure)
+	require.Equal(t, "1", c.GetString("b", ""))
+
+	require.PanicsWithError(t, "simulating reload: the configuration has no file, create it with New, NewFromString or NewFromFile", func() {
+		Load(nil).SimulateReload(nil)
+	})
+}
+
+func TestConfig_AssertAllRead(t *testing.T) {
+	tt := []struct {
+		name     string
+		config   func(t *testing.T) *Config
+		expected string
+	}{
+		{
+			name: "Load",
+			config: func(t *testing.T) *Config {
+				return Load(map[string]string{"a": "1", "b": "${a}", "db.port": "3", "d": "4"})
+			},
+			expected: "configuration properties never read: d, db.port",
+		},
+		{
+			name: "New",
+			config: func(t *testing.T) *Config {
+				return New(t, map[string]string{"a": "1", "b": "${a}", "db.port": "3", "d": "4"})
+			},
+			expected: "configuration properties never read: d, db.port",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			c := tc.config(t)
+			ft := &fakeT{TB: t}
+
+			require.Equal(t, "1", c.GetString("b", ""))
+
+			// When
+			c.AssertAllRead(ft)
+
+			// Then
+			require.Equal(t, tc.expected, ft.failure)
+
+			// When
+			require.Equal(t, 3, c.Sub("db").GetInt("port", 0))
+			require.Equal(t, 4, c.GetInt("d", 0))
+			c.AssertAllRead(t)
+		})
+	}
+}
+
+func TestWriteConfigFile(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	filename := WriteConfigFile(t, "string=value\n")
+
+	// Then
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+	require.Equal(t, []string{filename}, cfg.Layers())
+}
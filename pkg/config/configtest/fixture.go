@@ -0,0 +1,176 @@
+package configtest
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+)
+
+const _configFileName = "configFileName"
+
+// New returns a configuration holding the properties of m, overridden in turn
+// by the ones of overrides. Unlike Load, the configuration is loaded from a
+// temporary properties file, the way config.Load does, so that it can be
+// reloaded, see SimulateReload. The file is removed when the test completes.
+// Reads are tracked, see AssertAllRead.
+func New(t testing.TB, m map[string]string, overrides ...map[string]string) *Config {
+	t.Helper()
+
+	return NewFromString(t, config.FormatProperties(m), overrides...)
+}
+
+// NewFromString returns a configuration holding the properties written in
+// content, in the Java properties format, overridden in turn by the ones of
+// overrides, as New does.
+func NewFromString(t testing.TB, content string, overrides ...map[string]string) *Config {
+	t.Helper()
+
+	filename := writeFile(t, withOverrides(content, overrides))
+
+	cfg, err := config.LoadFile(filename, config.WithScope(), config.WithDir(), config.WithAccessTracking())
+	if err != nil {
+		t.Fatalf("loading configuration fixture: %v", err)
+	}
+
+	return &Config{Config: cfg, t: t, filename: filename}
+}
+
+// NewFromFile returns a configuration holding the properties of the given
+// properties file, usually found in testdata, overridden in turn by the ones
+// of overrides, as New does. The file doesn't need a checksum file.
+func NewFromFile(t testing.TB, filename string, overrides ...map[string]string) *Config {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("loading configuration fixture: %v", err)
+	}
+
+	return NewFromString(t, string(b), overrides...)
+}
+
+// WriteConfigFile writes content to a temporary properties file, along with
+// its MD5 checksum file, and points the configFileName environment variable
+// at it until the test completes, so that config.Load reads it. It returns the
+// name of the file.
+func WriteConfigFile(t testing.TB, content string) string {
+	t.Helper()
+
+	filename := writeFile(t, content)
+	t.Setenv(_configFileName, filename)
+
+	return filename
+}
+
+// Set sets the value of the property, as config.Config.Set does, failing the
+// test if the configuration becomes invalid.
+func (c *Config) Set(key, value string) {
+	c.helper()
+	c.check(c.Config.Set(key, value))
+}
+
+// Delete removes the property, as config.Config.Delete does, failing the test
+// if the configuration becomes invalid.
+func (c *Config) Delete(key string) {
+	c.helper()
+	c.check(c.Config.Delete(key))
+}
+
+// SimulateReload replaces the content of the file of the configuration by the
+// properties of m and reloads it, as done when the configuration is modified
+// while the application runs. The OnChange callbacks are called when the
+// properties changed, and the properties modified by Set and Delete are kept.
+// It fails the test if the configuration isn't valid, or wasn't created by
+// New, NewFromString or NewFromFile.
+func (c *Config) SimulateReload(m map[string]string) {
+	c.helper()
+
+	if c.filename == "" {
+		c.check(errors.New("simulating reload: the configuration has no file, create it with New, NewFromString or NewFromFile"))
+		return
+	}
+
+	writeChecked(c.t, c.filename, config.FormatProperties(m))
+	c.check(c.Config.Reload())
+}
+
+// AssertAllRead fails the test when some properties of the configuration were
+// never read, directly or through a reference, reporting them. It is meant to
+// be called once the code under test ran, to keep fixtures free of properties
+// that are no longer used.
+func (c *Config) AssertAllRead(t testing.TB) {
+	t.Helper()
+
+	if unused := c.AccessReport().Unused; len(unused) > 0 {
+		t.Errorf("configuration properties never read: %s", strings.Join(unused, ", "))
+	}
+}
+
+// helper marks the caller as a test helper, when the configuration is bound to
+// a test.
+func (c *Config) helper() {
+	if c.t != nil {
+		c.t.Helper()
+	}
+}
+
+// check fails the test bound to the configuration when err isn't nil, or
+// panics when it isn't bound to any.
+func (c *Config) check(err error) {
+	if err == nil {
+		return
+	}
+
+	if c.t == nil {
+		panic(err)
+	}
+
+	c.t.Helper()
+	c.t.Fatal(err)
+}
+
+// writeFile writes content to a properties file in a temporary directory
+// removed when the test completes.
+func writeFile(t testing.TB, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeChecked(t, filename, content)
+
+	return filename
+}
+
+// writeChecked writes content to filename along with its MD5 checksum file.
+func writeChecked(t testing.TB, filename, content string) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing configuration fixture: %v", err)
+	}
+
+	if err := config.WriteChecksumFile(filename, "md5"); err != nil {
+		t.Fatalf("writing configuration fixture: %v", err)
+	}
+}
+
+// withOverrides appends the properties of overrides to content, as the last
+// definition of a property wins.
+func withOverrides(content string, overrides []map[string]string) string {
+	for _, m := range overrides {
+		if len(m) == 0 {
+			continue
+		}
+
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+
+		content += config.FormatProperties(m)
+	}
+
+	return content
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	_redacted = "********"
+
+	_checksumVerified = "verified"
+	_checksumSkipped  = "skipped"
+)
+
+// _secretWords are the words that make a key look like it holds a secret.
+var _secretWords = []string{
+	"password", "passwd", "pwd", "secret", "token", "credential",
+	"apikey", "api_key", "api-key", "api.key",
+	"privatekey", "private_key", "private-key", "private.key",
+}
+
+type debugState struct {
+	LoadedAt   time.Time                `json:"loadedAt"`
+	Layers     []debugLayer             `json:"layers"`
+	Properties map[string]debugProperty `json:"properties"`
+}
+
+type debugLayer struct {
+	Path     string        `json:"path"`
+	Checksum debugChecksum `json:"checksum"`
+	SignedBy string        `json:"signedBy,omitempty"`
+}
+
+type debugChecksum struct {
+	Algorithm string `json:"algorithm,omitempty"`
+	Sum       string `json:"sum,omitempty"`
+	// Result is "verified", or "skipped" when checksums are disabled or don't
+	// apply, as for directories.
+	Result string `json:"result"`
+}
+
+type debugProperty struct {
+	Value    string `json:"value"`
+	Source   string `json:"source"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+type debugError struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP serves a JSON description of the configuration in use, for
+// debugging purposes: when it was loaded, the files it was loaded from along
+// with their checksum verification, and the effective value and source of
+// every property. Only GET requests are supported.
+//
+// Values are redacted when their key looks like it holds a secret, such as
+// db.password or api.token, when they are encrypted, or when they reference
+// such a property or environment variable.
+func (p *Config) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(debugError{Error: "Only GET is supported."}) //nolint:errcheck
+
+		return
+	}
+
+	enc.Encode(p.debugState()) //nolint:errcheck
+}
+
+func (p *Config) debugState() debugState {
+	s := p.snapshot()
+
+	state := debugState{
+		LoadedAt:   s.loaded,
+		Layers:     []debugLayer{},
+		Properties: map[string]debugProperty{},
+	}
+
+	for _, l := range s.layers {
+		c := debugChecksum{Algorithm: l.checksum, Sum: l.sum, Result: _checksumVerified}
+		if l.checksum == "" {
+			c.Result = _checksumSkipped
+		}
+
+		state.Layers = append(state.Layers, debugLayer{Path: l.filename, Checksum: c, SignedBy: l.signer})
+	}
+
+	for _, k := range p.Keys() {
+		prop := debugProperty{Value: p.value(k)}
+		prop.Source, _ = p.Source(k)
+
+		if p.sensitive(s, p.key(k), map[string]bool{}) {
+			prop.Value, prop.Redacted = _redacted, true
+		}
+
+		state.Properties[k] = prop
+	}
+
+	return state
+}
+
+// sensitive reports whether the value of key in s may disclose a secret.
+func (p *Config) sensitive(s *snapshot, key string, seen map[string]bool) bool {
+	if secretKey(key) {
+		return true
+	}
+
+	if seen[key] {
+		return false
+	}
+
+	seen[key] = true
+
+	v, ok := p.raw(s, key)
+	if !ok {
+		return false
+	}
+
+	if _, ok := encrypted(v); ok {
+		return true
+	}
+
+	for _, ref := range references(v) {
+		if p.sensitive(s, strings.TrimPrefix(ref, _refEnv), seen) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretKey reports whether key looks like it holds a secret.
+func secretKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, w := range _secretWords {
+		if strings.Contains(key, w) {
+			return true
+		}
+	}
+
+	return false
+}
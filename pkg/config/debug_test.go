@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeHTTP(t *testing.T) {
+	// Given
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	encrypted, err := EncryptValue(key, "hunter2")
+	require.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.host=localhost\n"+
+		"db.password=s3cr3t\n"+
+		"db.dsn=postgres://app:${db.password}@${db.host}\n"+
+		"cache.url=redis://${env:REDIS_TOKEN:-none}@cache\n"+
+		"smtp.login="+encrypted+"\n")
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+
+	before := time.Now()
+
+	cfg, err := Load(WithEncryptionKey(key))
+	require.NoError(t, err)
+
+	// When
+	rec := httptest.NewRecorder()
+	cfg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	// Then
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NotContains(t, rec.Body.String(), "s3cr3t")
+	require.NotContains(t, rec.Body.String(), "hunter2")
+
+	var state debugState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+
+	require.False(t, state.LoadedAt.Before(before))
+	require.Len(t, state.Layers, 1)
+	require.Equal(t, filename, state.Layers[0].Path)
+	require.Equal(t, "md5", state.Layers[0].Checksum.Algorithm)
+	require.Len(t, state.Layers[0].Checksum.Sum, 32)
+	require.Equal(t, "verified", state.Layers[0].Checksum.Result)
+
+	require.Equal(t, map[string]debugProperty{
+		"db.host":     {Value: "localhost", Source: filename},
+		"db.password": {Value: "********", Source: filename, Redacted: true},
+		"db.dsn":      {Value: "********", Source: filename, Redacted: true},
+		"cache.url":   {Value: "********", Source: filename, Redacted: true},
+		"smtp.login":  {Value: "********", Source: filename, Redacted: true},
+	}, state.Properties)
+}
+
+func TestServeHTTP_checksumDisabled(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	// When
+	rec := httptest.NewRecorder()
+	cfg.Sub("json").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	// Then
+	var state debugState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+
+	require.Equal(t, []debugLayer{{
+		Path:     "testdata/valid.properties",
+		Checksum: debugChecksum{Result: "skipped"},
+	}}, state.Layers)
+	require.Equal(t, []string{"car.property"}, keys(state.Properties))
+}
+
+func TestServeHTTP_methodNotAllowed(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"string": "value"})
+
+	// When
+	rec := httptest.NewRecorder()
+	cfg.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/config", nil))
+
+	// Then
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	require.JSONEq(t, `{"error":"Only GET is supported."}`, rec.Body.String())
+}
+
+func keys(m map[string]debugProperty) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/magiconair/properties"
+)
+
+const _configDir = "configDir"
+
+// WithDir merges the given directories on top of the configuration file and
+// its scopes, the last directory winning. Each file of a directory holds one
+// property, named after the file, whose value is the content of the file with
+// leading and trailing white space removed. This is the layout of Kubernetes
+// ConfigMap and Secret volumes, whose ..data indirection is supported as hidden
+// files and directories are ignored.
+//
+// As such mounts have no checksum or signature files, the files of a directory
+// are neither checksummed nor signed. Loading fails when signatures are
+// required, see WithTrustedKeys, unless WithUnsignedDirs is given, since
+// directories would otherwise override signed properties.
+//
+// When this option is not given, the directories are taken from the
+// comma-separated configDir environment variable.
+func WithDir(dirs ...string) Option {
+	return func(c *loadConfig) {
+		c.dirs = append([]string{}, dirs...)
+	}
+}
+
+// WithUnsignedDirs allows directories, see WithDir, along with trusted keys,
+// see WithTrustedKeys. Their properties are then trusted as the ones of signed
+// files, so they must only be writable by whoever signs the files.
+func WithUnsignedDirs() Option {
+	return func(c *loadConfig) {
+		c.unsignedDirs = true
+	}
+}
+
+// dirsFromEnv returns the directories configured in the environment.
+func dirsFromEnv() []string {
+	var dirs []string
+
+	for _, d := range strings.Split(os.Getenv(_configDir), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+
+	return dirs
+}
+
+// dirFiles returns the files of dir holding properties, keyed by property.
+func dirFiles(dir string) (map[string]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		filename := filepath.Join(dir, e.Name())
+
+		// Entries are symlinks to ..data/<key> in Kubernetes mounts, stat the
+		// target.
+		info, err := os.Stat(filename)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		files[e.Name()] = filename
+	}
+
+	return files, nil
+}
+
+// readDir reads the properties held by the files of dir.
+func readDir(dir string) (*properties.Properties, layerState, error) {
+	state := layerState{filename: dir}
+
+	files, err := dirFiles(dir)
+	if err != nil {
+		return nil, state, fmt.Errorf("reading configuration: %v", err)
+	}
+
+	m := make(map[string]string, len(files))
+
+	for k, filename := range files {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, state, fmt.Errorf("reading configuration: %v", err)
+		}
+
+		m[k] = strings.TrimSpace(string(b))
+	}
+
+	return loadMap(m), state, nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeMount writes files the way Kubernetes mounts ConfigMap and Secret
+// volumes: each key is a symlink to ..data/<key>, ..data itself being a
+// symlink to a timestamped directory that is swapped on updates.
+func writeMount(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+
+	data := filepath.Join(dir, "..2021_"+version)
+	require.NoError(t, os.MkdirAll(data, 0o700))
+
+	for k, v := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(data, k), []byte(v), 0o600))
+
+		link := filepath.Join(dir, k)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			require.NoError(t, os.Symlink(filepath.Join("..data", k), link))
+		}
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	require.NoError(t, os.Symlink(filepath.Base(data), tmp))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+}
+
+func TestLoad_dir(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "application.properties")
+	writeConfig(t, filename, "db.host=localhost\ndb.password=changeme\n")
+
+	secrets := filepath.Join(dir, "secrets")
+	writeMount(t, secrets, "1", map[string]string{
+		"db.password": "s3cr3t\n",
+		"db.user":     "  app  ",
+	})
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configDir", secrets)
+
+	// When
+	cfg, err := Load()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"db.host":     "localhost",
+		"db.password": "s3cr3t",
+		"db.user":     "app",
+	}, cfg.GetAll())
+	require.Equal(t, []string{filename, secrets}, cfg.Layers())
+
+	source, ok := cfg.Source("db.password")
+	require.True(t, ok)
+	require.Equal(t, filepath.Join(secrets, "db.password"), source)
+}
+
+func TestReload_dir(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "application.properties")
+	writeConfig(t, filename, "db.host=localhost\n")
+
+	secrets := filepath.Join(dir, "secrets")
+	writeMount(t, secrets, "1", map[string]string{"db.password": "s3cr3t"})
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load(WithDir(secrets))
+	require.NoError(t, err)
+
+	stamp := cfg.fingerprint()
+	require.Equal(t, stamp, cfg.fingerprint())
+
+	// When
+	writeMount(t, secrets, "2", map[string]string{"db.password": "rotated", "db.user": "app"})
+
+	// Then
+	require.NotEqual(t, stamp, cfg.fingerprint())
+	require.NoError(t, cfg.Reload())
+	require.Equal(t, "rotated", cfg.GetString("db.password", ""))
+	require.Equal(t, "app", cfg.GetString("db.user", ""))
+}
+
+func TestLoad_dirErr(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.host=localhost\n")
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	_, err := Load(WithDir(filepath.Join(filepath.Dir(filename), "missing")))
+
+	// Then
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reading configuration: open ")
+}
+
+func TestLoad_dirSigned(t *testing.T) {
+	// Given
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "application.properties")
+	writeConfig(t, filename, "db.host=localhost\n")
+	require.NoError(t, SignFile(filename, priv))
+
+	secrets := filepath.Join(dir, "secrets")
+	writeMount(t, secrets, "1", map[string]string{"db.host": "overridden"})
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configDir", secrets)
+
+	trusted := WithTrustedKeys(TrustedKey{ID: "release", Key: pub})
+
+	// When
+	_, err = Load(trusted)
+
+	// Then
+	require.EqualError(t, err, "loading configuration: directories "+secrets+" can't be signed, see WithUnsignedDirs")
+
+	// When
+	cfg, err := Load(trusted, WithUnsignedDirs())
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "overridden", cfg.GetString("db.host", ""))
+}
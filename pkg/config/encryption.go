@@ -0,0 +1,195 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+const (
+	_encryptionKey     = "configEncryptionKey"
+	_encryptionKeyFile = "configEncryptionKeyFile"
+	_encryptedPrefix   = "ENC("
+	_encryptedSuffix   = ")"
+)
+
+// ErrDecrypt is reported when an encrypted value can't be decrypted. Use
+// errors.Is to check for it.
+var ErrDecrypt = errors.New("decrypting value")
+
+// WithEncryptionKey sets the AES key, 16, 24 or 32 bytes long, used to decrypt
+// the values written as ENC(base64), as returned by EncryptValue. Encrypted
+// values are decrypted by every getter, and loading a configuration holding a
+// value that can't be decrypted fails.
+//
+// When this option is not given, the key is read from the file named by the
+// configEncryptionKeyFile environment variable or, failing that, from the
+// configEncryptionKey environment variable. Both hold the base64 encoding of
+// the key.
+func WithEncryptionKey(key []byte) Option {
+	return func(c *loadConfig) {
+		c.encryptionKey = append([]byte{}, key...)
+	}
+}
+
+// WithoutDecryption leaves encrypted values as written: they are neither
+// checked when loading nor decrypted when read, so that no key is needed. It is
+// meant for tools working on files as written, such as deerconfig diff.
+func WithoutDecryption() Option {
+	return func(c *loadConfig) {
+		c.keepEncrypted = true
+	}
+}
+
+// encryptionKeyFromEnv returns the key configured in the environment, if any.
+func encryptionKeyFromEnv() ([]byte, error) {
+	encoded := os.Getenv(_encryptionKey)
+
+	if filename := os.Getenv(_encryptionKeyFile); filename != "" {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading encryption key: %v", err)
+		}
+
+		encoded = string(b)
+	}
+
+	if encoded = strings.TrimSpace(encoded); encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding encryption key: %v", err)
+	}
+
+	return key, nil
+}
+
+// GenerateEncryptionKey returns a random 32 bytes long key, to be used with
+// EncryptValue and WithEncryptionKey.
+func GenerateEncryptionKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// EncryptValue encrypts plaintext with AES-GCM, returning the value to write
+// in the configuration file: ENC(base64), the base64 encoded data being the
+// nonce followed by the ciphertext.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return _encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + _encryptedSuffix, nil
+}
+
+// DecryptValue decrypts a value returned by EncryptValue. Values that are not
+// encrypted are returned unchanged.
+func DecryptValue(key []byte, value string) (string, error) {
+	data, ok := encrypted(value)
+	if !ok {
+		return value, nil
+	}
+
+	if len(key) == 0 {
+		return "", fmt.Errorf("%w: no encryption key configured", ErrDecrypt)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("%w: ciphertext too short", ErrDecrypt)
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// encrypted returns the base64 data of value when it is written as
+// ENC(base64).
+func encrypted(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, _encryptedPrefix) || !strings.HasSuffix(value, _encryptedSuffix) {
+		return "", false
+	}
+
+	return value[len(_encryptedPrefix) : len(value)-len(_encryptedSuffix)], true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decrypt decrypts value with the configured key if it is encrypted, unless
+// encrypted values are kept, see WithoutDecryption.
+func (p *Config) decrypt(value string) (string, error) {
+	if p.keepEncrypted {
+		return value, nil
+	}
+
+	return DecryptValue(p.encryptionKey, value)
+}
+
+// checkEncrypted makes sure every encrypted value of prop can be decrypted,
+// so that a wrong key is noticed when loading rather than when reading.
+func (p *Config) checkEncrypted(s *snapshot) error {
+	if p.keepEncrypted {
+		return nil
+	}
+
+	var errs []error
+
+	for _, k := range s.prop.Keys() {
+		v, _ := s.prop.Get(k)
+		if _, err := p.decrypt(v); err != nil {
+			errs = append(errs, &KeyError{Key: k, Err: err})
+		}
+	}
+
+	if err := multierr.Combine(errs...); err != nil {
+		return fmt.Errorf("decrypting configuration: %v", err)
+	}
+
+	return nil
+}
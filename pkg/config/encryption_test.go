@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptValue(t *testing.T) {
+	// Given
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	// When
+	v, err := EncryptValue(key, "s3cr3t")
+
+	// Then
+	require.NoError(t, err)
+	require.Regexp(t, `^ENC\([A-Za-z0-9+/=]+\)$`, v)
+
+	plaintext, err := DecryptValue(key, v)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", plaintext)
+
+	plaintext, err = DecryptValue(key, "plain")
+	require.NoError(t, err)
+	require.Equal(t, "plain", plaintext)
+}
+
+func TestLoad_encrypted(t *testing.T) {
+	// Given
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	password, err := EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "application.properties")
+	writeConfig(t, filename, "db.user=app\ndb.password="+password+"\n")
+
+	keyFile := filepath.Join(dir, "config.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600))
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configEncryptionKeyFile", keyFile)
+
+	// When
+	cfg, err := Load()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", cfg.GetString("db.password", ""))
+	require.Equal(t, "app", cfg.GetString("db.user", ""))
+	require.Equal(t, "s3cr3t", cfg.GetAll()["db.password"])
+}
+
+func TestLoad_encryptedErr(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	otherKey, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	password, err := EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+
+	tt := []struct {
+		name string
+		key  []byte
+		err  string
+	}{
+		{
+			name: "no key",
+			err:  "decrypting configuration: key db.password: decrypting value: no encryption key configured",
+		},
+		{
+			name: "wrong key",
+			key:  otherKey,
+			err:  "decrypting configuration: key db.password: decrypting value: cipher: message authentication failed",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			filename := filepath.Join(t.TempDir(), "application.properties")
+			writeConfig(t, filename, "db.password="+password+"\n")
+
+			t.Setenv("configFileName", filename)
+			t.Setenv("checksumEnabled", "true")
+			t.Setenv("configEncryptionKey", "")
+			t.Setenv("configEncryptionKeyFile", "")
+
+			// When
+			_, err := Load(WithEncryptionKey(tc.key))
+
+			// Then
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestLookupString_encryptedErr(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"db.password": "ENC(not base64)"})
+
+	// When
+	_, err := cfg.LookupString("db.password")
+
+	// Then
+	require.True(t, errors.Is(err, ErrDecrypt))
+	require.Equal(t, "default", cfg.GetString("db.password", "default"))
+}
+
+func TestLookup_encryptedRedacted(t *testing.T) {
+	// Given
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	port, err := EncryptValue(key, "s3cr3t-password")
+	require.NoError(t, err)
+
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.port="+port+"\ndb.url=jdbc://host:${db.port}\ndb.mode="+port+"\n")
+
+	cfg, err := LoadFile(filename, WithEncryptionKey(key))
+	require.NoError(t, err)
+
+	var settings struct {
+		Port int    `config:"db.port"`
+		Mode string `config:"db.mode" validate:"oneof=read write"`
+	}
+
+	// When
+	errs := []error{
+		func() error { _, err := cfg.LookupInt("db.port"); return err }(),
+		func() error { _, err := cfg.LookupDuration("db.port"); return err }(),
+		func() error { _, err := cfg.LookupURL("db.url"); return err }(),
+		func() error { _, err := cfg.Sub("db").LookupIntSlice("port"); return err }(),
+		cfg.Bind(&settings),
+	}
+
+	// Then
+	for _, err := range errs {
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), "s3cr3t")
+		require.Contains(t, err.Error(), _redacted)
+	}
+
+	require.EqualError(t, errs[0], `key db.port: malformed value "********": invalid syntax`)
+	require.ErrorIs(t, errs[0], strconv.ErrSyntax)
+
+	require.PanicsWithError(t, `key db.port: malformed value "********": invalid syntax`, func() {
+		cfg.MustInt("db.port")
+	})
+}
+
+func TestLoad_withoutDecryption(t *testing.T) {
+	// Given
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	password, err := EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configEncryptionKey", "")
+	t.Setenv("configEncryptionKeyFile", "")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.password="+password+"\ndb.dsn=app:${db.password}@localhost\n")
+
+	// When
+	cfg, err := LoadFile(filename, WithoutDecryption())
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, password, cfg.GetString("db.password", ""))
+	require.Equal(t, "app:"+password+"@localhost", cfg.Sub("db").GetString("dsn", ""))
+	require.NoError(t, cfg.Set("db.user", "app"))
+
+	_, err = LoadFile(filename)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no encryption key configured")
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// envOverrides maps property keys to the environment variables overriding them.
+type envOverrides struct {
+	prefix string
+}
+
+// WithEnvOverrides lets the environment override any property. The variable
+// overriding a key is the upper-cased key with dots and dashes replaced by
+// underscores, preceded by the given prefix, if any. For instance, with the
+// prefix "MYAPP" the key "db.pool.size" is overridden by MYAPP_DB_POOL_SIZE.
+//
+// Overrides are applied by every getter, including GetAll, which applies them
+// to the keys present in the configuration.
+func WithEnvOverrides(prefix string) Option {
+	return func(c *loadConfig) {
+		c.env = &envOverrides{
+			prefix: strings.TrimSuffix(prefix, "_"),
+		}
+	}
+}
+
+// name returns the environment variable overriding key.
+func (e *envOverrides) name(key string) string {
+	name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if e.prefix == "" {
+		return name
+	}
+
+	return e.prefix + "_" + name
+}
+
+// lookupEnv returns the environment variable override of key, if any.
+func (p *Config) lookupEnv(key string) (string, bool) {
+	if p.env == nil {
+		return "", false
+	}
+
+	return os.LookupEnv(p.env.name(key))
+}
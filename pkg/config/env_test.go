@@ -0,0 +1,93 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEnvOverrides(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+	t.Setenv("MYAPP_STRING", "overridden")
+	t.Setenv("MYAPP_INT", "42")
+	t.Setenv("MYAPP_BOOL", "false")
+	t.Setenv("MYAPP_FORMAT_DURATION", "1s")
+	t.Setenv("MYAPP_INT_LIST", "1,2")
+	t.Setenv("MYAPP_STRING_LIST", "x,y")
+	t.Setenv("MYAPP_JSON_CAR_PROPERTY", `{"model":"Fusca"}`)
+	t.Setenv("MYAPP_DB_POOL_SIZE", "10")
+
+	// When
+	cfg, err := Load(WithEnvOverrides("MYAPP_"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "overridden", cfg.GetString("string", ""))
+	require.Equal(t, 42, cfg.GetInt("int", 0))
+	require.Equal(t, uint(42), cfg.GetUint("int", 0))
+	require.Equal(t, false, cfg.GetBool("bool", true))
+	require.Equal(t, time.Second, cfg.GetParsedDuration("format.duration", 0))
+	require.Equal(t, 9.12, cfg.GetFloat64("float", 0))
+	require.Equal(t, []int{1, 2}, cfg.GetIntSlice("int.list", nil))
+	require.Equal(t, []string{"x", "y"}, cfg.GetStringSlice("string.list", nil))
+	require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+
+	var car Car
+	require.NoError(t, cfg.GetJSONPropertyAndUnmarshal("json.car.property", &car))
+	require.Equal(t, "Fusca", car.Model)
+
+	all := cfg.GetAll()
+	require.Equal(t, "overridden", all["string"])
+	require.Equal(t, "42", all["int"])
+	require.Equal(t, "9.12", all["float"])
+	require.NotContains(t, all, "db.pool.size")
+}
+
+func TestWithEnvOverrides_noPrefix(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+	t.Setenv("STRING", "overridden")
+
+	// When
+	cfg, err := Load(WithEnvOverrides(""))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "overridden", cfg.GetString("string", ""))
+}
+
+func TestWithEnvOverrides_disabled(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+	t.Setenv("STRING", "overridden")
+
+	// When
+	cfg, err := Load()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+}
+
+func TestEnvOverridesName(t *testing.T) {
+	tt := []struct {
+		prefix   string
+		key      string
+		expected string
+	}{
+		{prefix: "", key: "db.pool.size", expected: "DB_POOL_SIZE"},
+		{prefix: "MYAPP", key: "db.pool.size", expected: "MYAPP_DB_POOL_SIZE"},
+		{prefix: "MYAPP", key: "http-client.timeout", expected: "MYAPP_HTTP_CLIENT_TIMEOUT"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.key, func(t *testing.T) {
+			e := &envOverrides{prefix: tc.prefix}
+			require.Equal(t, tc.expected, e.name(tc.key))
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"gopkg.in/yaml.v3"
+)
+
+// format parses configuration files of a given syntax into properties.
+type format func(b []byte) (*properties.Properties, error)
+
+// _formats are the formats of configuration files, by extension.
+var _formats = map[string]format{
+	".yaml": parseYAML,
+	".yml":  parseYAML,
+	".json": parseJSON,
+}
+
+// formatFor returns the format of filename, picked from its extension. Files
+// with an unknown extension are parsed as Java properties.
+func formatFor(filename string) format {
+	if f, ok := _formats[strings.ToLower(filepath.Ext(filename))]; ok {
+		return f
+	}
+
+	return parseProperties
+}
+
+// isProperties reports whether filename is parsed as Java properties.
+func isProperties(filename string) bool {
+	_, ok := _formats[strings.ToLower(filepath.Ext(filename))]
+	return !ok
+}
+
+// ReadFile returns the properties of the given configuration file as written,
+// parsed according to its format, see LoadFile. Unlike LoadFile, it neither
+// verifies the file against its checksum and signature files nor interpolates
+// or decrypts its values.
+func ReadFile(filename string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration: %v", err)
+	}
+
+	prop, err := formatFor(filename)(b)
+	if err != nil {
+		return nil, fmt.Errorf("loading configuration: %v", err)
+	}
+
+	return prop.Map(), nil
+}
+
+// newProperties returns empty properties. Their expansion is disabled as
+// references are interpolated by Config, see interpolate.
+func newProperties() *properties.Properties {
+	p := properties.NewProperties()
+	p.DisableExpansion = true
+
+	return p
+}
+
+// loadMap returns properties holding m, in the order of its sorted keys.
+func loadMap(m map[string]string) *properties.Properties {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	p := newProperties()
+	for _, k := range keys {
+		p.Set(k, m[k]) //nolint:errcheck // Set never fails when expansion is disabled.
+	}
+
+	return p
+}
+
+func parseProperties(b []byte) (*properties.Properties, error) {
+	l := &properties.Loader{Encoding: properties.UTF8, DisableExpansion: true}
+	return l.LoadBytes(b)
+}
+
+func parseYAML(b []byte) (*properties.Properties, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	return flatten(v)
+}
+
+func parseJSON(b []byte) (*properties.Properties, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return flatten(v)
+}
+
+// flatten turns a document decoded from YAML or JSON into properties, nested
+// maps being joined into dotted keys, so that {"db": {"pool": {"size": 10}}}
+// becomes db.pool.size=10. Lists of scalars are written as comma-separated
+// values, as expected by the slice getters, while other lists are kept as
+// JSON to be read with GetJSONPropertyAndUnmarshal.
+func flatten(v interface{}) (*properties.Properties, error) {
+	m := map[string]string{}
+
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		if err := flattenMap(m, "", v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("the document must be a map, got %T", v)
+	}
+
+	return loadMap(m), nil
+}
+
+// flattenMap flattens the entries of v, in the order of their sorted keys so
+// that errors don't depend on the order of map iteration.
+func flattenMap(m map[string]string, prefix string, v map[string]interface{}) error {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := flattenValue(m, prefix+k, v[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flattenValue flattens v into m under key. A key given twice, as a nested map
+// and as a dotted key, is an error.
+func flattenValue(m map[string]string, key string, v interface{}) error {
+	if _, ok := m[key]; ok {
+		return fmt.Errorf("duplicate key %s", key)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return flattenMap(m, key+".", v)
+	case map[interface{}]interface{}:
+		s := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			if _, ok := s[fmt.Sprint(k)]; ok {
+				return fmt.Errorf("duplicate key %s%v", key+".", k)
+			}
+
+			s[fmt.Sprint(k)] = e
+		}
+
+		return flattenMap(m, key+".", s)
+	case []interface{}:
+		s, err := flattenList(v)
+		if err != nil {
+			return fmt.Errorf("key %s: %v", key, err)
+		}
+
+		m[key] = s
+	case nil:
+		m[key] = ""
+	default:
+		m[key] = fmt.Sprint(v)
+	}
+
+	return nil
+}
+
+// flattenList returns the comma-separated values of list, quoted as needed,
+// or its JSON encoding when it doesn't only hold scalars.
+func flattenList(list []interface{}) (string, error) {
+	values := make([]string, 0, len(list))
+
+	for _, e := range list {
+		switch e.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			b, err := json.Marshal(list)
+			return string(b), err
+		case nil:
+			values = append(values, "")
+		default:
+			values = append(values, fmt.Sprint(e))
+		}
+	}
+
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	if err := w.Write(values); err != nil {
+		return "", err
+	}
+
+	w.Flush()
+
+	return strings.TrimSuffix(buf.String(), "\n"), w.Error()
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_formats(t *testing.T) {
+	for _, filename := range []string{"testdata/nested.yaml", "testdata/nested.json"} {
+		t.Run(filename, func(t *testing.T) {
+			// Given
+			t.Setenv("configFileName", filename)
+			t.Setenv("checksumEnabled", "true")
+
+			// When
+			cfg, err := Load()
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{
+				"db.host":         "localhost",
+				"db.pool.size":    "10",
+				"db.pool.timeout": "1.5s",
+				"db.replicas":     "replica-1,replica-2",
+				"feature.enabled": "true",
+				"feature.ratio":   "0.25",
+				"feature.ids":     "1,2,3",
+				"labels":          `"a,b",c`,
+				"servers":         `[{"name":"primary","port":8080}]`,
+			}, cfg.GetAll())
+
+			require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+			require.Equal(t, 1500*time.Millisecond, cfg.GetParsedDuration("db.pool.timeout", 0))
+			require.Equal(t, []string{"replica-1", "replica-2"}, cfg.GetStringSlice("db.replicas", nil))
+			require.Equal(t, []int{1, 2, 3}, cfg.GetIntSlice("feature.ids", nil))
+			require.Equal(t, []string{"a,b", "c"}, cfg.GetStringSlice("labels", nil))
+
+			var servers []struct {
+				Name string
+				Port int
+			}
+			require.NoError(t, cfg.GetJSONPropertyAndUnmarshal("servers", &servers))
+			require.Equal(t, 8080, servers[0].Port)
+		})
+	}
+}
+
+func TestLoad_formatsErr(t *testing.T) {
+	tt := []struct {
+		name     string
+		filename string
+		content  string
+		err      string
+	}{
+		{
+			name:     "invalid yaml",
+			filename: "application.yaml",
+			content:  "db: [",
+			err:      "loading configuration: yaml: line 1: did not find expected node content",
+		},
+		{
+			name:     "invalid json",
+			filename: "application.json",
+			content:  `{"db": `,
+			err:      "loading configuration: unexpected EOF",
+		},
+		{
+			name:     "duplicate key",
+			filename: "application.yaml",
+			content:  "db:\n  pool: 1\ndb.pool: 2\n",
+			err:      "loading configuration: duplicate key db.pool",
+		},
+		{
+			name:     "duplicate json key",
+			filename: "application.json",
+			content:  `{"a.b": {"c": 1}, "a": {"b.c": 2}}`,
+			err:      "loading configuration: duplicate key a.b.c",
+		},
+		{
+			name:     "not a map",
+			filename: "application.yml",
+			content:  "- a\n- b\n",
+			err:      "loading configuration: the document must be a map, got []interface {}",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			filename := filepath.Join(t.TempDir(), tc.filename)
+			writeConfig(t, filename, tc.content)
+
+			t.Setenv("configFileName", filename)
+			t.Setenv("checksumEnabled", "true")
+
+			// When
+			_, err := Load()
+
+			// Then
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestLoad_formatsChecksum(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.yaml")
+	writeConfig(t, filename, "db:\n  host: localhost\n")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("db:\n  host: attacker\n"), 0o600))
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	_, err := Load()
+
+	// Then
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "verifying configuration: md5 checksum mismatch")
+}
+
+func TestReadFile(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("db:\n  url: jdbc://${db.host}/x\n  password: ENC(x)\n"), 0o600))
+
+	// When
+	values, err := ReadFile(filename)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"db.url": "jdbc://${db.host}/x", "db.password": "ENC(x)"}, values)
+
+	_, err = ReadFile(filepath.Join(t.TempDir(), "missing.properties"))
+	require.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+const (
+	_refPrefix     = "${"
+	_refSuffix     = "}"
+	_refEnv        = "env:"
+	_refDefaultSep = ":-"
+	// _refEscape is written for a literal "${", which doesn't start a reference.
+	_refEscape = "$" + _refPrefix
+)
+
+// ErrInterpolate is reported when the references of a value can't be
+// resolved. Use errors.Is to check for it.
+var ErrInterpolate = errors.New("interpolating value")
+
+// interpolate returns the value of key in s with its references replaced by
+// their values:
+//
+//   - ${name} is replaced by the value of the property name or, if it doesn't
+//     exist, by the environment variable name.
+//   - ${env:NAME} is replaced by the environment variable NAME.
+//   - ${name:-default} and ${env:NAME:-default} are replaced by default when
+//     the property or variable is missing or empty.
+//   - $${ is replaced by a literal ${, which doesn't start a reference.
+//
+// Referenced properties are interpolated and decrypted in turn. chain holds
+// the properties being interpolated, to report circular references. It
+// returns false when key doesn't exist.
+func (p *Config) interpolate(s *snapshot, key string, chain []string) (string, bool, error) {
+	v, ok := p.raw(s, key)
+	if !ok {
+		return "", false, nil
+	}
+
+	for _, k := range chain {
+		if k == key {
+			return "", true, fmt.Errorf("%w: circular reference %s", ErrInterpolate, strings.Join(append(chain, key), " -> "))
+		}
+	}
+
+	chain = append(chain[:len(chain):len(chain)], key)
+
+	var b strings.Builder
+
+	err := scan(v, func(text string) {
+		b.WriteString(text)
+	}, func(ref string) error {
+		v, err := p.resolve(s, ref, chain)
+		b.WriteString(v)
+
+		return err
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	return b.String(), true, nil
+}
+
+// scan splits v into text, passed to text with its escapes replaced, and
+// references, passed to ref without their ${} delimiters. It stops at the first
+// error ref returns, or at an unterminated reference.
+func scan(v string, text func(s string), ref func(r string) error) error {
+	for {
+		start := strings.Index(v, _refPrefix)
+		if start < 0 {
+			text(v)
+			return nil
+		}
+
+		if start > 0 && strings.HasPrefix(v[start-1:], _refEscape) {
+			text(v[:start-1] + _refPrefix)
+			v = v[start+len(_refPrefix):]
+
+			continue
+		}
+
+		end := strings.Index(v[start:], _refSuffix)
+		if end < 0 {
+			return fmt.Errorf("%w: unterminated reference in %q", ErrInterpolate, v)
+		}
+
+		text(v[:start])
+
+		if err := ref(v[start+len(_refPrefix) : start+end]); err != nil {
+			return err
+		}
+
+		v = v[start+end+len(_refSuffix):]
+	}
+}
+
+// resolve returns the value of the reference ref, written without its ${}
+// delimiters.
+func (p *Config) resolve(s *snapshot, ref string, chain []string) (string, error) {
+	name, def, hasDefault := cut(ref, _refDefaultSep)
+
+	var (
+		v  string
+		ok bool
+	)
+
+	if env := strings.TrimPrefix(name, _refEnv); env != name {
+		v, ok = os.LookupEnv(env)
+	} else {
+		var err error
+		if v, ok, err = p.interpolate(s, name, chain); err != nil {
+			return "", err
+		}
+
+		if ok {
+			if v, err = p.decrypt(v); err != nil {
+				return "", err
+			}
+		} else {
+			v, ok = os.LookupEnv(name)
+		}
+	}
+
+	if hasDefault && v == "" {
+		return def, nil
+	}
+
+	if !ok {
+		return "", fmt.Errorf("%w: unresolved reference %s%s%s", ErrInterpolate, _refPrefix, ref, _refSuffix)
+	}
+
+	return v, nil
+}
+
+// references returns the names of the properties and environment variables,
+// the latter prefixed by "env:", referenced by v.
+func references(v string) []string {
+	var refs []string
+
+	scan(v, func(string) {}, func(ref string) error { //nolint:errcheck // Unterminated references are reported when loading.
+		name, _, _ := cut(ref, _refDefaultSep)
+		refs = append(refs, name)
+
+		return nil
+	})
+
+	return refs
+}
+
+// checkReferences makes sure the references of every property of s can be
+// resolved, so that circular or missing references are noticed when loading
+// rather than when reading. Values that can't be decrypted are reported by
+// checkEncrypted, not by the properties referencing them.
+func (p *Config) checkReferences(s *snapshot) error {
+	var errs []error
+
+	for _, k := range s.prop.Keys() {
+		if _, _, err := p.interpolate(s, k, nil); err != nil && !errors.Is(err, ErrDecrypt) {
+			errs = append(errs, &KeyError{Key: k, Err: err})
+		}
+	}
+
+	if err := multierr.Combine(errs...); err != nil {
+		return fmt.Errorf("interpolating configuration: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+)
+
+const _configScope = "configScope"
+
+// layer is one of the files merged into a Config.
+type layer struct {
+	filename string
+	// optional layers are skipped when their file doesn't exist.
+	optional bool
+	// dir layers are directories holding one property per file, see WithDir.
+	dir bool
+	// remote is set for configurations served over HTTP, see Load.
+	remote *remote
+}
+
+// WithScope merges the scope-specific files on top of the base configuration
+// file. For the base file application.properties and the scopes "prod" and
+// "prod-read", the files application-prod.properties and
+// application-prod-read.properties are merged in that order, the last one
+// winning. Scope files that don't exist are skipped; those present are
+// verified on their own.
+//
+// When this option is not given, the scopes are taken from the comma-separated
+// configScope environment variable.
+func WithScope(scopes ...string) Option {
+	return func(c *loadConfig) {
+		c.scopes = append([]string{}, scopes...)
+	}
+}
+
+// scopesFromEnv returns the scopes configured in the environment.
+func scopesFromEnv() []string {
+	var scopes []string
+
+	for _, s := range strings.Split(os.Getenv(_configScope), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
+
+// newLayers returns the layers for the given base file, scopes and
+// directories, lowest precedence first.
+func newLayers(filename string, scopes, dirs []string) []layer {
+	layers := []layer{{filename: filename}}
+
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+
+	if isURL(filename) {
+		scopes = nil
+	}
+
+	for _, s := range scopes {
+		layers = append(layers, layer{
+			filename: base + "-" + s + ext,
+			optional: true,
+		})
+	}
+
+	for _, d := range dirs {
+		layers = append(layers, layer{filename: d, dir: true})
+	}
+
+	return layers
+}
+
+// source returns the file the given property of the layer is read from.
+func (l layer) source(key string) string {
+	if l.dir {
+		return filepath.Join(l.filename, key)
+	}
+
+	return l.filename
+}
+
+// readLayer reads the properties of the given layer.
+func (p *Config) readLayer(l layer) (*properties.Properties, layerState, error) {
+	switch {
+	case l.dir:
+		return readDir(l.filename)
+	case l.remote != nil:
+		return p.readRemote(l.remote)
+	default:
+		return p.read(l.filename)
+	}
+}
+
+// readLayers reads and merges the layers of the configuration.
+func (p *Config) readLayers() (*snapshot, error) {
+	s := &snapshot{
+		prop:    newProperties(),
+		sources: map[string]string{},
+		loaded:  time.Now(),
+	}
+
+	for _, l := range p.layers {
+		if l.optional {
+			if _, err := os.Stat(l.filename); os.IsNotExist(err) {
+				continue
+			}
+		}
+
+		prop, state, err := p.readLayer(l)
+		if err != nil {
+			return nil, err
+		}
+
+		s.prop.Merge(prop)
+
+		for _, k := range prop.Keys() {
+			s.sources[k] = l.source(k)
+		}
+
+		s.layers = append(s.layers, state)
+	}
+
+	for _, e := range p.edits {
+		e.apply(s)
+	}
+
+	if err := p.check(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// check makes sure the properties of s can be used.
+func (p *Config) check(s *snapshot) error {
+	if err := p.checkReferences(s); err != nil {
+		return err
+	}
+
+	if err := p.checkEncrypted(s); err != nil {
+		return err
+	}
+
+	if p.schema != nil {
+		if err := p.schema.Check(p.pinned(s)); err != nil {
+			return fmt.Errorf("checking configuration schema: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// pinned returns a configuration reading from s rather than from the
+// properties in use, to check properties before using them. It resolves the
+// deprecated keys of p, see Alias.
+func (p *Config) pinned(s *snapshot) *Config {
+	c := &Config{
+		env:           p.env,
+		encryptionKey: p.encryptionKey,
+		keepEncrypted: p.keepEncrypted,
+	}
+
+	p.aliases.pin(&c.aliases)
+	c.state.Store(s)
+
+	return c
+}
+
+// Layers returns the files that make up the configuration, lowest precedence
+// first. Scope files that were skipped because they don't exist are not
+// included.
+func (p *Config) Layers() []string {
+	var filenames []string
+	for _, l := range p.snapshot().layers {
+		filenames = append(filenames, l.filename)
+	}
+
+	return filenames
+}
+
+// Source returns where the effective value of key comes from: the file of the
+// layer that supplied it or, when overridden, the environment variable name
+// prefixed by "env:". It returns false when the key doesn't exist.
+func (p *Config) Source(key string) (string, bool) {
+	key = p.key(key)
+
+	if _, ok := p.lookupEnv(key); ok {
+		return "env:" + p.env.name(key), true
+	}
+
+	s, ok := p.snapshot().sources[key]
+
+	return s, ok
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_scopes(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	base := filepath.Join(dir, "application.properties")
+	prod := filepath.Join(dir, "application-prod.properties")
+	prodRead := filepath.Join(dir, "application-prod-read.properties")
+	writeConfig(t, base, "string=base\nint=1\nbool=true\n")
+	writeConfig(t, prod, "string=prod\nint=2\n")
+	writeConfig(t, prodRead, "int=3\n")
+	t.Setenv("configFileName", base)
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configScope", "prod, prod-read,missing")
+	t.Setenv("INT", "4")
+
+	// When
+	cfg, err := Load()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"string": "prod", "int": "3", "bool": "true"}, cfg.GetAll())
+	require.Equal(t, []string{base, prod, prodRead}, cfg.Layers())
+
+	source, ok := cfg.Source("bool")
+	require.True(t, ok)
+	require.Equal(t, base, source)
+
+	source, ok = cfg.Source("string")
+	require.True(t, ok)
+	require.Equal(t, prod, source)
+
+	source, ok = cfg.Source("int")
+	require.True(t, ok)
+	require.Equal(t, prodRead, source)
+
+	_, ok = cfg.Source("non-existent-value")
+	require.False(t, ok)
+}
+
+func TestLoad_scopesOption(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	base := filepath.Join(dir, "application.properties")
+	writeConfig(t, base, "string=base\n")
+	writeConfig(t, filepath.Join(dir, "application-test.properties"), "string=test\n")
+	writeConfig(t, filepath.Join(dir, "application-prod.properties"), "string=prod\n")
+	t.Setenv("configFileName", base)
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configScope", "prod")
+	t.Setenv("STRING", "env")
+
+	// When
+	cfg, err := Load(WithScope("test"), WithEnvOverrides(""))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "env", cfg.GetString("string", ""))
+
+	source, ok := cfg.Source("string")
+	require.True(t, ok)
+	require.Equal(t, "env:STRING", source)
+}
+
+func TestLoad_scopeInvalidChecksum(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	base := filepath.Join(dir, "application.properties")
+	scoped := filepath.Join(dir, "application-prod.properties")
+	writeConfig(t, base, "string=base\n")
+	writeConfig(t, scoped, "string=prod\n")
+	require.NoError(t, ioutil.WriteFile(scoped, []byte("string=tampered\n"), 0o600))
+	t.Setenv("configFileName", base)
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	_, err := Load(WithScope("prod"))
+
+	// Then
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "verifying configuration: md5 checksum mismatch for "+scoped)
+}
+
+func TestNewLayers(t *testing.T) {
+	layers := newLayers("/configs/latest/application.properties", []string{"prod", "prod-read"}, []string{"/configs/secrets"})
+
+	require.Equal(t, []layer{
+		{filename: "/configs/latest/application.properties"},
+		{filename: "/configs/latest/application-prod.properties", optional: true},
+		{filename: "/configs/latest/application-prod-read.properties", optional: true},
+		{filename: "/configs/secrets", dir: true},
+	}, layers)
+}
@@ -0,0 +1,614 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config/utils"
+)
+
+// ErrNotFound is reported by the Lookup getters when the property doesn't
+// exist. Use errors.Is to check for it.
+var ErrNotFound = errors.New("not found")
+
+// KeyError reports a property that doesn't exist, can't be parsed or doesn't
+// satisfy its validation rules.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("key %s: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError reports a property whose value can't be parsed. Use errors.As to
+// retrieve it from the errors returned by the Lookup getters.
+//
+// When the value may disclose a secret, such as an encrypted value or the value
+// of db.password, Value is redacted and Err doesn't quote it.
+type ParseError struct {
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("malformed value %q: %v", e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// lookup retrieves the value of the property, interpolating its references and
+// decrypting it if needed. It reports a *KeyError wrapping ErrNotFound when the
+// property doesn't exist, or wrapping ErrInterpolate or ErrDecrypt when it
+// can't be resolved.
+func (p *Config) lookup(key string) (string, error) {
+	s := p.snapshot()
+
+	v, err := p.find(s, key)
+	if !errors.Is(err, ErrNotFound) {
+		p.deprecated(s, p.key(key))
+	}
+
+	p.track(s, p.key(key), err)
+
+	return v, err
+}
+
+// find resolves the property from s as lookup does, without recording the read
+// nor warning about deprecated keys.
+func (p *Config) find(s *snapshot, key string) (string, error) {
+	v, ok, err := p.interpolate(s, p.key(key), nil)
+	if !ok {
+		return "", &KeyError{Key: key, Err: ErrNotFound}
+	}
+
+	if err == nil {
+		v, err = p.decrypt(v)
+	}
+
+	if err != nil {
+		return "", &KeyError{Key: key, Err: err}
+	}
+
+	return v, nil
+}
+
+// malformed returns the error reported when the value of key can't be parsed.
+func malformed(key, value string, err error) error {
+	return &KeyError{Key: key, Err: &ParseError{Value: value, Err: err}}
+}
+
+// errRedacted replaces the parse errors of values that must not be disclosed.
+var errRedacted = errors.New("details redacted")
+
+// redacted returns the error reported when the value of key can't be parsed
+// and may disclose a secret. Neither the value nor the parse error, which
+// usually quotes it, are reported, only the cause of strconv errors.
+func redacted(key string, err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	} else {
+		err = errRedacted
+	}
+
+	return &KeyError{Key: key, Err: &ParseError{Value: _redacted, Err: err}}
+}
+
+// parseError returns the error reported when the value of key can't be parsed,
+// redacted when the value may disclose a secret.
+func (p *Config) parseError(key, value string, err error) error {
+	if p.secret(key) {
+		return redacted(key, err)
+	}
+
+	return malformed(key, value, err)
+}
+
+// secret reports whether the value of key may disclose a secret, the way
+// ServeHTTP tells the values to redact.
+func (p *Config) secret(key string) bool {
+	return p.sensitive(p.snapshot(), p.key(key), map[string]bool{})
+}
+
+// LookupBool retrieve the property as bool value, it is true when the value is
+// one of "1", "true", "yes" or "on", and false when it is one of "0", "false",
+// "no" or "off", ignoring case. Any other value is malformed.
+func (p *Config) LookupBool(key string) (bool, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := parseBool(v)
+	if err != nil {
+		return false, p.reject(key, v, err)
+	}
+
+	return b, nil
+}
+
+// LookupString retrieve the property as string value
+func (p *Config) LookupString(key string) (string, error) {
+	return p.lookup(key)
+}
+
+// LookupInt retrieve the property as int value
+func (p *Config) LookupInt(key string) (int, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, p.reject(key, v, err)
+	}
+
+	return i, nil
+}
+
+// LookupFloat64 retrieve the property as float value
+func (p *Config) LookupFloat64(key string) (float64, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, p.reject(key, v, err)
+	}
+
+	return f, nil
+}
+
+// LookupUint retrieve the property as uint value
+func (p *Config) LookupUint(key string) (uint, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	u, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, p.reject(key, v, err)
+	}
+
+	return uint(u), nil
+}
+
+// LookupDuration retrieve the property as duration value, expressed in
+// nanoseconds
+func (p *Config) LookupDuration(key string) (time.Duration, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, p.reject(key, v, err)
+	}
+
+	return time.Duration(d), nil
+}
+
+// LookupParsedDuration retrieve the property as duration parsed with time.ParseDuration()
+func (p *Config) LookupParsedDuration(key string) (time.Duration, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, p.reject(key, v, err)
+	}
+
+	return d, nil
+}
+
+// LookupStringSlice retrieve the property as string list values
+func (p *Config) LookupStringSlice(key string) ([]string, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := utils.ConvertStringToList(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return list, nil
+}
+
+// LookupIntSlice retrieve the property as int list values
+func (p *Config) LookupIntSlice(key string) ([]int, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := utils.ConvertStringToList(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	result, err := utils.ConvertStringArrayToIntArray(list)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return result, nil
+}
+
+// LookupFloatSlice retrieve the property as float list values
+func (p *Config) LookupFloatSlice(key string) ([]float64, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := utils.ConvertStringToList(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	result, err := utils.ConvertStringArrayToFloatArray(list)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return result, nil
+}
+
+// MustBool retrieve the property as bool value, it panics if the property
+// doesn't exist.
+func (p *Config) MustBool(key string) bool {
+	v, err := p.LookupBool(key)
+	must(err)
+
+	return v
+}
+
+// MustString retrieve the property as string value, it panics if the property
+// doesn't exist.
+func (p *Config) MustString(key string) string {
+	v, err := p.LookupString(key)
+	must(err)
+
+	return v
+}
+
+// MustInt retrieve the property as int value, it panics if the property
+// doesn't exist or can't be parsed.
+func (p *Config) MustInt(key string) int {
+	v, err := p.LookupInt(key)
+	must(err)
+
+	return v
+}
+
+// MustFloat64 retrieve the property as float value, it panics if the property
+// doesn't exist or can't be parsed.
+func (p *Config) MustFloat64(key string) float64 {
+	v, err := p.LookupFloat64(key)
+	must(err)
+
+	return v
+}
+
+// MustUint retrieve the property as uint value, it panics if the property
+// doesn't exist or can't be parsed.
+func (p *Config) MustUint(key string) uint {
+	v, err := p.LookupUint(key)
+	must(err)
+
+	return v
+}
+
+// MustDuration retrieve the property as duration value, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustDuration(key string) time.Duration {
+	v, err := p.LookupDuration(key)
+	must(err)
+
+	return v
+}
+
+// MustParsedDuration retrieve the property as duration parsed with
+// time.ParseDuration(), it panics if the property doesn't exist or can't be
+// parsed.
+func (p *Config) MustParsedDuration(key string) time.Duration {
+	v, err := p.LookupParsedDuration(key)
+	must(err)
+
+	return v
+}
+
+// MustStringSlice retrieve the property as string list values, it panics if
+// the property doesn't exist or can't be parsed.
+func (p *Config) MustStringSlice(key string) []string {
+	v, err := p.LookupStringSlice(key)
+	must(err)
+
+	return v
+}
+
+// MustIntSlice retrieve the property as int list values, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustIntSlice(key string) []int {
+	v, err := p.LookupIntSlice(key)
+	must(err)
+
+	return v
+}
+
+// MustFloatSlice retrieve the property as float list values, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustFloatSlice(key string) []float64 {
+	v, err := p.LookupFloatSlice(key)
+	must(err)
+
+	return v
+}
+
+// LookupByteSize retrieve the property as a number of bytes, such as "10MB" or
+// "512KiB", see parseByteSize for the units
+func (p *Config) LookupByteSize(key string) (int64, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := parseByteSize(v)
+	if err != nil {
+		return 0, p.reject(key, v, err)
+	}
+
+	return n, nil
+}
+
+// LookupURL retrieve the property as an absolute URL
+func (p *Config) LookupURL(key string) (*url.URL, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := parseURL(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return u, nil
+}
+
+// LookupIP retrieve the property as an IPv4 or IPv6 address
+func (p *Config) LookupIP(key string) (net.IP, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ip, err := parseIP(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return ip, nil
+}
+
+// LookupCIDR retrieve the property as a network in CIDR notation, such as
+// "192.168.0.0/16"
+func (p *Config) LookupCIDR(key string) (*net.IPNet, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	_, network, err := net.ParseCIDR(strings.TrimSpace(v))
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return network, nil
+}
+
+// LookupRegexp retrieve the property as a compiled regular expression
+func (p *Config) LookupRegexp(key string) (*regexp.Regexp, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return re, nil
+}
+
+// LookupTime retrieve the property as a time in the RFC 3339 format, such as
+// "2006-01-02T15:04:05Z07:00"
+func (p *Config) LookupTime(key string) (time.Time, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+	if err != nil {
+		return time.Time{}, p.reject(key, v, err)
+	}
+
+	return t, nil
+}
+
+// LookupBoolSlice retrieve the property as bool list values, each one parsed as by
+// LookupBool
+func (p *Config) LookupBoolSlice(key string) ([]bool, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := utils.ConvertStringToList(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	result := make([]bool, len(list))
+	for i, e := range list {
+		if result[i], err = parseBool(strings.TrimSpace(e)); err != nil {
+			return nil, p.reject(key, v, err)
+		}
+	}
+
+	return result, nil
+}
+
+// LookupDurationSlice retrieve the property as duration list values, each one
+// expressed in nanoseconds or parsed with time.ParseDuration()
+func (p *Config) LookupDurationSlice(key string) ([]time.Duration, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := utils.ConvertStringToList(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	result := make([]time.Duration, len(list))
+	for i, e := range list {
+		if result[i], err = parseDuration(strings.TrimSpace(e)); err != nil {
+			return nil, p.reject(key, v, err)
+		}
+	}
+
+	return result, nil
+}
+
+// LookupStringMap retrieve the property as a map from comma-separated key=value
+// pairs, such as "a=1,b=2"
+func (p *Config) LookupStringMap(key string) (map[string]string, error) {
+	v, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseStringMap(v)
+	if err != nil {
+		return nil, p.reject(key, v, err)
+	}
+
+	return m, nil
+}
+
+// MustByteSize retrieve the property as a number of bytes, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustByteSize(key string) int64 {
+	v, err := p.LookupByteSize(key)
+	must(err)
+
+	return v
+}
+
+// MustURL retrieve the property as an absolute URL, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustURL(key string) *url.URL {
+	v, err := p.LookupURL(key)
+	must(err)
+
+	return v
+}
+
+// MustIP retrieve the property as an IP address, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustIP(key string) net.IP {
+	v, err := p.LookupIP(key)
+	must(err)
+
+	return v
+}
+
+// MustCIDR retrieve the property as a network in CIDR notation, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustCIDR(key string) *net.IPNet {
+	v, err := p.LookupCIDR(key)
+	must(err)
+
+	return v
+}
+
+// MustRegexp retrieve the property as a compiled regular expression, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustRegexp(key string) *regexp.Regexp {
+	v, err := p.LookupRegexp(key)
+	must(err)
+
+	return v
+}
+
+// MustTime retrieve the property as an RFC 3339 time, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustTime(key string) time.Time {
+	v, err := p.LookupTime(key)
+	must(err)
+
+	return v
+}
+
+// MustBoolSlice retrieve the property as bool list values, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustBoolSlice(key string) []bool {
+	v, err := p.LookupBoolSlice(key)
+	must(err)
+
+	return v
+}
+
+// MustDurationSlice retrieve the property as duration list values, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustDurationSlice(key string) []time.Duration {
+	v, err := p.LookupDurationSlice(key)
+	must(err)
+
+	return v
+}
+
+// MustStringMap retrieve the property as a map, it panics if the
+// property doesn't exist or can't be parsed.
+func (p *Config) MustStringMap(key string) map[string]string {
+	v, err := p.LookupStringMap(key)
+	must(err)
+
+	return v
+}
+
+// must panics with err, which names the key and its raw value, if not nil.
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	// Then
+	b, err := cfg.LookupBool("bool")
+	require.NoError(t, err)
+	require.True(t, b)
+
+	s, err := cfg.LookupString("string")
+	require.NoError(t, err)
+	require.Equal(t, "value", s)
+
+	i, err := cfg.LookupInt("int")
+	require.NoError(t, err)
+	require.Equal(t, 9, i)
+
+	u, err := cfg.LookupUint("int")
+	require.NoError(t, err)
+	require.Equal(t, uint(9), u)
+
+	f, err := cfg.LookupFloat64("float")
+	require.NoError(t, err)
+	require.Equal(t, 9.12, f)
+
+	d, err := cfg.LookupDuration("duration")
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(91218), d)
+
+	d, err = cfg.LookupParsedDuration("format.duration")
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(91218), d)
+
+	ss, err := cfg.LookupStringSlice("string.list")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a1,a2", "b1", "c1"}, ss)
+
+	is, err := cfg.LookupIntSlice("int.list")
+	require.NoError(t, err)
+	require.Equal(t, []int{10, 15, 90}, is)
+
+	fs, err := cfg.LookupFloatSlice("float.list")
+	require.NoError(t, err)
+	require.Equal(t, []float64{1.0, 1.5, 9.0}, fs)
+}
+
+func TestLookup_err(t *testing.T) {
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	tt := []struct {
+		name          string
+		lookup        func() error
+		malformed     bool
+		expectedError string
+	}{
+		{
+			name:          "missing bool",
+			lookup:        func() error { _, err := cfg.LookupBool("non-existent-value"); return err },
+			expectedError: "key non-existent-value: not found",
+		},
+		{
+			name:          "missing string",
+			lookup:        func() error { _, err := cfg.LookupString("non-existent-value"); return err },
+			expectedError: "key non-existent-value: not found",
+		},
+		{
+			name:          "malformed int",
+			lookup:        func() error { _, err := cfg.LookupInt("string"); return err },
+			malformed:     true,
+			expectedError: `key string: malformed value "value": strconv.Atoi: parsing "value": invalid syntax`,
+		},
+		{
+			name:          "malformed uint",
+			lookup:        func() error { _, err := cfg.LookupUint("float"); return err },
+			malformed:     true,
+			expectedError: `key float: malformed value "9.12": strconv.ParseUint: parsing "9.12": invalid syntax`,
+		},
+		{
+			name:          "malformed float",
+			lookup:        func() error { _, err := cfg.LookupFloat64("string"); return err },
+			malformed:     true,
+			expectedError: `key string: malformed value "value": strconv.ParseFloat: parsing "value": invalid syntax`,
+		},
+		{
+			name:          "malformed duration",
+			lookup:        func() error { _, err := cfg.LookupDuration("format.duration"); return err },
+			malformed:     true,
+			expectedError: `key format.duration: malformed value "91.218µs": strconv.ParseInt: parsing "91.218µs": invalid syntax`,
+		},
+		{
+			name:          "malformed parsed duration",
+			lookup:        func() error { _, err := cfg.LookupParsedDuration("string"); return err },
+			malformed:     true,
+			expectedError: `key string: malformed value "value": time: invalid duration "value"`,
+		},
+		{
+			name:          "malformed int list",
+			lookup:        func() error { _, err := cfg.LookupIntSlice("int.invalid.list"); return err },
+			malformed:     true,
+			expectedError: `key int.invalid.list: malformed value "10,15,a": strconv.Atoi: parsing "a": invalid syntax`,
+		},
+		{
+			name:          "malformed float list",
+			lookup:        func() error { _, err := cfg.LookupFloatSlice("int.invalid.list"); return err },
+			malformed:     true,
+			expectedError: `key int.invalid.list: malformed value "10,15,a": strconv.ParseFloat: parsing "a": invalid syntax`,
+		},
+		{
+			name:          "missing string list",
+			lookup:        func() error { _, err := cfg.LookupStringSlice("non-existent-value"); return err },
+			expectedError: "key non-existent-value: not found",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.lookup()
+
+			require.EqualError(t, err, tc.expectedError)
+
+			var keyErr *KeyError
+			require.True(t, errors.As(err, &keyErr))
+
+			var parseErr *ParseError
+			require.Equal(t, tc.malformed, errors.As(err, &parseErr))
+			require.Equal(t, !tc.malformed, errors.Is(err, ErrNotFound))
+		})
+	}
+}
+
+func TestMust(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, true, cfg.MustBool("bool"))
+	require.Equal(t, "value", cfg.MustString("string"))
+	require.Equal(t, 9, cfg.MustInt("int"))
+	require.Equal(t, uint(9), cfg.MustUint("int"))
+	require.Equal(t, 9.12, cfg.MustFloat64("float"))
+	require.Equal(t, time.Duration(91218), cfg.MustDuration("duration"))
+	require.Equal(t, time.Duration(91218), cfg.MustParsedDuration("format.duration"))
+	require.Equal(t, []string{"a1,a2", "b1", "c1"}, cfg.MustStringSlice("string.list"))
+	require.Equal(t, []int{10, 15, 90}, cfg.MustIntSlice("int.list"))
+	require.Equal(t, []float64{1.0, 1.5, 9.0}, cfg.MustFloatSlice("float.list"))
+
+	require.PanicsWithError(t, "key non-existent-value: not found", func() {
+		cfg.MustString("non-existent-value")
+	})
+	require.PanicsWithError(t, `key int.invalid.list: malformed value "10,15,a": strconv.Atoi: parsing "a": invalid syntax`, func() {
+		cfg.MustIntSlice("int.invalid.list")
+	})
+}
+
+func TestLookup_types(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{
+		"size":           "10MB",
+		"endpoint":       "https://example.com:8443/api?v=1",
+		"ip":             "10.0.0.1",
+		"ip6":            "::1",
+		"network":        "192.168.0.0/16",
+		"pattern":        "^user-[0-9]+$",
+		"time":           "2021-06-01T12:30:00+02:00",
+		"bool.list":      "true,false, true",
+		"bool.words":     "On,off,YES,no,1,0",
+		"duration.list":  "1000,5s,1m30s",
+		"map":            "a=1,b=2",
+		"invalid.string": "not valid",
+	})
+
+	// Then
+	n, err := cfg.LookupByteSize("size")
+	require.NoError(t, err)
+	require.Equal(t, int64(10_000_000), n)
+
+	u, err := cfg.LookupURL("endpoint")
+	require.NoError(t, err)
+	require.Equal(t, "example.com:8443", u.Host)
+	require.Equal(t, "/api", u.Path)
+
+	ip, err := cfg.LookupIP("ip")
+	require.NoError(t, err)
+	require.True(t, net.IPv4(10, 0, 0, 1).Equal(ip))
+
+	ip, err = cfg.LookupIP("ip6")
+	require.NoError(t, err)
+	require.True(t, net.IPv6loopback.Equal(ip))
+
+	network, err := cfg.LookupCIDR("network")
+	require.NoError(t, err)
+	require.Equal(t, "192.168.0.0/16", network.String())
+	require.True(t, network.Contains(net.IPv4(192, 168, 3, 4)))
+
+	re, err := cfg.LookupRegexp("pattern")
+	require.NoError(t, err)
+	require.True(t, re.MatchString("user-42"))
+
+	tm, err := cfg.LookupTime("time")
+	require.NoError(t, err)
+	require.True(t, time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC).Equal(tm))
+
+	bs, err := cfg.LookupBoolSlice("bool.list")
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, true}, bs)
+
+	bs, err = cfg.LookupBoolSlice("bool.words")
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, true, false, true, false}, bs)
+
+	ds, err := cfg.LookupDurationSlice("duration.list")
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{1000, 5 * time.Second, 90 * time.Second}, ds)
+
+	m, err := cfg.LookupStringMap("map")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, m)
+
+	require.Equal(t, int64(10_000_000), cfg.MustByteSize("size"))
+	require.Equal(t, "192.168.0.0/16", cfg.MustCIDR("network").String())
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, cfg.MustStringMap("map"))
+	require.PanicsWithError(t, `key invalid.string: malformed value "not valid": missing = in "not valid"`, func() {
+		cfg.MustStringMap("invalid.string")
+	})
+}
+
+func TestLookup_typesErr(t *testing.T) {
+	cfg := LoadMap(map[string]string{
+		"string":    "not valid",
+		"relative":  "/api",
+		"pattern":   "a(b",
+		"time":      "2021-06-01 12:30:00",
+		"durations": "5s,soon",
+		"bool":      "ture",
+		"bools":     "true,flase",
+	})
+
+	tt := []struct {
+		name          string
+		lookup        func() error
+		expectedError string
+	}{
+		{
+			name:          "malformed byte size",
+			lookup:        func() error { _, err := cfg.LookupByteSize("string"); return err },
+			expectedError: `key string: malformed value "not valid": strconv.ParseFloat: parsing "": invalid syntax`,
+		},
+		{
+			name:          "relative url",
+			lookup:        func() error { _, err := cfg.LookupURL("relative"); return err },
+			expectedError: `key relative: malformed value "/api": missing scheme`,
+		},
+		{
+			name:          "malformed ip",
+			lookup:        func() error { _, err := cfg.LookupIP("string"); return err },
+			expectedError: `key string: malformed value "not valid": invalid IP address`,
+		},
+		{
+			name:          "malformed cidr",
+			lookup:        func() error { _, err := cfg.LookupCIDR("string"); return err },
+			expectedError: `key string: malformed value "not valid": invalid CIDR address: not valid`,
+		},
+		{
+			name:          "malformed regexp",
+			lookup:        func() error { _, err := cfg.LookupRegexp("pattern"); return err },
+			expectedError: "key pattern: malformed value \"a(b\": error parsing regexp: missing closing ): `a(b`",
+		},
+		{
+			name:          "malformed time",
+			lookup:        func() error { _, err := cfg.LookupTime("time"); return err },
+			expectedError: `key time: malformed value "2021-06-01 12:30:00": parsing time "2021-06-01 12:30:00" as "2006-01-02T15:04:05Z07:00": cannot parse " 12:30:00" as "T"`,
+		},
+		{
+			name:          "malformed duration list",
+			lookup:        func() error { _, err := cfg.LookupDurationSlice("durations"); return err },
+			expectedError: `key durations: malformed value "5s,soon": time: invalid duration "soon"`,
+		},
+		{
+			name:          "malformed bool",
+			lookup:        func() error { _, err := cfg.LookupBool("bool"); return err },
+			expectedError: `key bool: malformed value "ture": expected one of 1, true, yes, on, 0, false, no or off`,
+		},
+		{
+			name:          "malformed bool list",
+			lookup:        func() error { _, err := cfg.LookupBoolSlice("bools"); return err },
+			expectedError: `key bools: malformed value "true,flase": expected one of 1, true, yes, on, 0, false, no or off`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.lookup()
+
+			require.EqualError(t, err, tc.expectedError)
+
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+		})
+	}
+}
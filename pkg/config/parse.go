@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config/utils"
+)
+
+// _byteUnits are the multiples of the byte accepted by parseByteSize, keyed by
+// their lower-cased symbol.
+var _byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1 << 50,
+	"pb":  1e15,
+	"pib": 1 << 50,
+}
+
+// parseByteSize parses a number of bytes followed by an optional unit, such as
+// "10MB" or "512 KiB". Decimal units (kB, MB, GB, TB, PB) are powers of 1000,
+// binary units (KiB, MiB, GiB, TiB, PiB) and their single letter symbols (K,
+// M, G, T, P) powers of 1024. Units are case-insensitive.
+func parseByteSize(v string) (int64, error) {
+	v = strings.TrimSpace(v)
+
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(v)
+	}
+
+	n, err := strconv.ParseFloat(v[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	unit, ok := _byteUnits[strings.ToLower(strings.TrimSpace(v[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", strings.TrimSpace(v[i:]))
+	}
+
+	size := math.Round(n * unit)
+	// math.MaxInt64 converts to 2^63 as a float64, which doesn't fit.
+	if size >= math.MaxInt64 {
+		return 0, errors.New("value out of range")
+	}
+
+	return int64(size), nil
+}
+
+// parseURL parses an absolute URL.
+func parseURL(v string) (*url.URL, error) {
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "" {
+		return nil, errors.New("missing scheme")
+	}
+
+	return u, nil
+}
+
+// parseIP parses an IPv4 or IPv6 address.
+func parseIP(v string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(v))
+	if ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+
+	return ip, nil
+}
+
+// parseStringMap parses comma-separated key=value pairs, such as "a=1,b=2".
+// Pairs are quoted as list values when they contain commas.
+func parseStringMap(v string) (map[string]string, error) {
+	m := map[string]string{}
+	if strings.TrimSpace(v) == "" {
+		return m, nil
+	}
+
+	pairs, err := utils.ConvertStringToList(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pair := range pairs {
+		k, v, ok := cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing = in %q", pair)
+		}
+
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return m, nil
+}
+
+// errBool is reported for booleans that are neither true nor false words.
+var errBool = errors.New("expected one of 1, true, yes, on, 0, false, no or off")
+
+// parseBool parses v as true when it is one of "1", "true", "yes" or "on", and
+// as false when it is one of "0", "false", "no" or "off", ignoring case.
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	default:
+		return false, errBool
+	}
+}
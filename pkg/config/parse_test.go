@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tt := []struct {
+		value         string
+		expected      int64
+		expectedError string
+	}{
+		{value: "512", expected: 512},
+		{value: "512B", expected: 512},
+		{value: "10MB", expected: 10_000_000},
+		{value: "10mb", expected: 10_000_000},
+		{value: "1.5kB", expected: 1500},
+		{value: "512KiB", expected: 512 << 10},
+		{value: "512 KiB", expected: 512 << 10},
+		{value: "2G", expected: 2 << 30},
+		{value: " 1TiB ", expected: 1 << 40},
+		{value: "", expectedError: `strconv.ParseFloat: parsing "": invalid syntax`},
+		{value: "MB", expectedError: `strconv.ParseFloat: parsing "": invalid syntax`},
+		{value: "-1MB", expectedError: `strconv.ParseFloat: parsing "": invalid syntax`},
+		{value: "10XB", expectedError: `unknown unit "XB"`},
+		{value: "9000000PiB", expectedError: "value out of range"},
+		{value: "8191PiB", expected: 8191 << 50},
+		{value: "8192PiB", expectedError: "value out of range"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			// When
+			n, err := parseByteSize(tc.value)
+
+			// Then
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, n)
+		})
+	}
+}
+
+func TestParseStringMap(t *testing.T) {
+	tt := []struct {
+		value         string
+		expected      map[string]string
+		expectedError string
+	}{
+		{value: "", expected: map[string]string{}},
+		{value: "a=1,b=2", expected: map[string]string{"a": "1", "b": "2"}},
+		{value: " a = 1 , b=x=y", expected: map[string]string{"a": "1", "b": "x=y"}},
+		{value: `"a=1,2",b=`, expected: map[string]string{"a": "1,2", "b": ""}},
+		{value: "a=1,b", expectedError: `missing = in "b"`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			// When
+			m, err := parseStringMap(tc.value)
+
+			// Then
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, m)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Reader is the read-only view of a configuration. Both Config and
+// configtest.Config satisfy it, so components depending on a Reader rather
+// than on a concrete configuration can be unit-tested with configtest.
+type Reader interface {
+	// GetBool retrieve the property as bool value
+	GetBool(key string, value bool) bool
+
+	// GetString retrieve the property as string value
+	GetString(key string, value string) string
+
+	// GetInt retrieve the property as int value
+	GetInt(key string, value int) int
+
+	// GetFloat64 retrieve the property as float value
+	GetFloat64(key string, value float64) float64
+
+	// GetUint retrieve the property as uint value
+	GetUint(key string, value uint) uint
+
+	// GetDuration retrieve the property as duration value
+	GetDuration(key string, value time.Duration) time.Duration
+
+	// GetParsedDuration retrieve the property as duration parsed with time.ParseDuration()
+	GetParsedDuration(key string, value time.Duration) time.Duration
+
+	// GetAll retrieve all properties
+	GetAll() map[string]string
+
+	// Raw retrieves the property as written, without interpolating nor
+	// decrypting it
+	Raw(key string) (string, bool)
+
+	// Keys returns the keys of the configuration, sorted
+	Keys() []string
+
+	// Has reports whether the property exists
+	Has(key string) bool
+
+	// Range calls fn for each property whose key starts with prefix, until it
+	// returns false
+	Range(prefix string, fn func(key, value string) bool)
+
+	// Sub returns a view of the properties whose keys start with the given
+	// prefix followed by a dot
+	Sub(prefix string) *Config
+
+	// GetStringSlice retrieve the property as string list values
+	GetStringSlice(key string, defaultValues []string) []string
+
+	// GetIntSlice retrieve the property as int list values
+	GetIntSlice(key string, defaultValues []int) []int
+
+	// GetFloatSlice retrieve the property as float list values
+	GetFloatSlice(key string, defaultValues []float64) []float64
+
+	// GetByteSize retrieve the property as a number of bytes
+	GetByteSize(key string, value int64) int64
+
+	// GetURL retrieve the property as an absolute URL
+	GetURL(key string, value *url.URL) *url.URL
+
+	// GetIP retrieve the property as an IP address
+	GetIP(key string, value net.IP) net.IP
+
+	// GetCIDR retrieve the property as a network in CIDR notation
+	GetCIDR(key string, value *net.IPNet) *net.IPNet
+
+	// GetRegexp retrieve the property as a compiled regular expression
+	GetRegexp(key string, value *regexp.Regexp) *regexp.Regexp
+
+	// GetTime retrieve the property as an RFC 3339 time
+	GetTime(key string, value time.Time) time.Time
+
+	// GetBoolSlice retrieve the property as bool list values
+	GetBoolSlice(key string, defaultValues []bool) []bool
+
+	// GetDurationSlice retrieve the property as duration list values
+	GetDurationSlice(key string, defaultValues []time.Duration) []time.Duration
+
+	// GetStringMap retrieve the property as a map of key=value pairs
+	GetStringMap(key string, defaultValues map[string]string) map[string]string
+
+	// GetJSONPropertyAndUnmarshal Retrieve json property and unmarshal
+	GetJSONPropertyAndUnmarshal(key string, structType interface{}) error
+
+	// LookupBool retrieve the property as bool value
+	LookupBool(key string) (bool, error)
+
+	// LookupString retrieve the property as string value
+	LookupString(key string) (string, error)
+
+	// LookupInt retrieve the property as int value
+	LookupInt(key string) (int, error)
+
+	// LookupFloat64 retrieve the property as float value
+	LookupFloat64(key string) (float64, error)
+
+	// LookupUint retrieve the property as uint value
+	LookupUint(key string) (uint, error)
+
+	// LookupDuration retrieve the property as duration value
+	LookupDuration(key string) (time.Duration, error)
+
+	// LookupParsedDuration retrieve the property as duration parsed with time.ParseDuration()
+	LookupParsedDuration(key string) (time.Duration, error)
+
+	// LookupStringSlice retrieve the property as string list values
+	LookupStringSlice(key string) ([]string, error)
+
+	// LookupIntSlice retrieve the property as int list values
+	LookupIntSlice(key string) ([]int, error)
+
+	// LookupFloatSlice retrieve the property as float list values
+	LookupFloatSlice(key string) ([]float64, error)
+
+	// LookupByteSize retrieve the property as a number of bytes
+	LookupByteSize(key string) (int64, error)
+
+	// LookupURL retrieve the property as an absolute URL
+	LookupURL(key string) (*url.URL, error)
+
+	// LookupIP retrieve the property as an IP address
+	LookupIP(key string) (net.IP, error)
+
+	// LookupCIDR retrieve the property as a network in CIDR notation
+	LookupCIDR(key string) (*net.IPNet, error)
+
+	// LookupRegexp retrieve the property as a compiled regular expression
+	LookupRegexp(key string) (*regexp.Regexp, error)
+
+	// LookupTime retrieve the property as an RFC 3339 time
+	LookupTime(key string) (time.Time, error)
+
+	// LookupBoolSlice retrieve the property as bool list values
+	LookupBoolSlice(key string) ([]bool, error)
+
+	// LookupDurationSlice retrieve the property as duration list values
+	LookupDurationSlice(key string) ([]time.Duration, error)
+
+	// LookupStringMap retrieve the property as a map of key=value pairs
+	LookupStringMap(key string) (map[string]string, error)
+
+	// MustBool retrieve the property as bool value or panics
+	MustBool(key string) bool
+
+	// MustString retrieve the property as string value or panics
+	MustString(key string) string
+
+	// MustInt retrieve the property as int value or panics
+	MustInt(key string) int
+
+	// MustFloat64 retrieve the property as float value or panics
+	MustFloat64(key string) float64
+
+	// MustUint retrieve the property as uint value or panics
+	MustUint(key string) uint
+
+	// MustDuration retrieve the property as duration value or panics
+	MustDuration(key string) time.Duration
+
+	// MustParsedDuration retrieve the property as duration parsed with time.ParseDuration() or panics
+	MustParsedDuration(key string) time.Duration
+
+	// MustStringSlice retrieve the property as string list values or panics
+	MustStringSlice(key string) []string
+
+	// MustIntSlice retrieve the property as int list values or panics
+	MustIntSlice(key string) []int
+
+	// MustFloatSlice retrieve the property as float list values or panics
+	MustFloatSlice(key string) []float64
+
+	// MustByteSize retrieve the property as a number of bytes or panics
+	MustByteSize(key string) int64
+
+	// MustURL retrieve the property as an absolute URL or panics
+	MustURL(key string) *url.URL
+
+	// MustIP retrieve the property as an IP address or panics
+	MustIP(key string) net.IP
+
+	// MustCIDR retrieve the property as a network in CIDR notation or panics
+	MustCIDR(key string) *net.IPNet
+
+	// MustRegexp retrieve the property as a compiled regular expression or panics
+	MustRegexp(key string) *regexp.Regexp
+
+	// MustTime retrieve the property as an RFC 3339 time or panics
+	MustTime(key string) time.Time
+
+	// MustBoolSlice retrieve the property as bool list values or panics
+	MustBoolSlice(key string) []bool
+
+	// MustDurationSlice retrieve the property as duration list values or panics
+	MustDurationSlice(key string) []time.Duration
+
+	// MustStringMap retrieve the property as a map of key=value pairs or panics
+	MustStringMap(key string) map[string]string
+
+	// Bind populates the struct pointed to by v with the properties named by
+	// the "config" tag of its fields.
+	Bind(v interface{}) error
+}
+
+var _ Reader = (*Config)(nil)
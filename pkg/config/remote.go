@@ -0,0 +1,296 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+)
+
+const (
+	_checksumHeader  = "X-Config-Checksum"
+	_signatureHeader = "X-Config-Signature"
+	_configCacheDir  = "configCacheDir"
+
+	_defaultHTTPTimeout = 10 * time.Second
+)
+
+// WithHTTPClient sets the client used to fetch configurations served over
+// HTTP, see Load. It defaults to a client with a 10 seconds timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *loadConfig) {
+		c.client = client
+	}
+}
+
+// WithCacheDir sets the directory where the last valid configuration served
+// over HTTP is cached, see Load.
+//
+// When this option is not given, the directory is taken from the
+// configCacheDir environment variable. When none is set, nothing is cached.
+func WithCacheDir(dir string) Option {
+	return func(c *loadConfig) {
+		c.cacheDir = &dir
+	}
+}
+
+// isURL reports whether the configuration is served over HTTP.
+func isURL(filename string) bool {
+	return strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://")
+}
+
+// remote fetches a configuration served over HTTP. Watch polls it without
+// holding the lock of the configuration, its state being guarded by mu.
+type remote struct {
+	url    string
+	client *http.Client
+	// format parses the payload, picked from the extension of the URL path.
+	format format
+	// cache is the file the last valid payload is cached in, empty when
+	// caching is disabled.
+	cache string
+
+	mu sync.Mutex
+	// etag, header and body describe the last payload fetched, and polled
+	// tells whether poll fetched it, or failed to with pollErr, and it wasn't
+	// read since.
+	etag    string
+	header  http.Header
+	body    []byte
+	polled  bool
+	pollErr error
+}
+
+func newRemote(rawURL string, cfg loadConfig) (*remote, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("loading configuration: %v", err)
+	}
+
+	r := &remote{
+		url:    rawURL,
+		client: cfg.client,
+		format: formatFor(u.Path),
+	}
+
+	if r.client == nil {
+		r.client = &http.Client{Timeout: _defaultHTTPTimeout}
+	}
+
+	if cfg.cacheDir != nil && *cfg.cacheDir != "" {
+		sum := sha256.Sum256([]byte(rawURL))
+		r.cache = filepath.Join(*cfg.cacheDir, hex.EncodeToString(sum[:8])+path.Ext(u.Path))
+	}
+
+	return r, nil
+}
+
+// fetch returns the payload served at the URL. It sends the ETag of the last
+// payload fetched, if any, which is returned again when the server reports it
+// didn't change. r.mu must be held.
+func (r *remote) fetch() ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && r.body != nil:
+		return r.body, r.header, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil, fmt.Errorf("fetching %s: unexpected status %s", r.url, resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.etag, r.header, r.body = resp.Header.Get("ETag"), resp.Header, b
+
+	return b, resp.Header, nil
+}
+
+// poll fetches the configuration, returning a value that changes whenever the
+// payload does. The payload, or the error when it couldn't be fetched, is kept
+// for the next read, so that a load or reload following a poll doesn't fetch
+// it again, waiting twice for an unreachable server.
+func (r *remote) poll() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, _, err := r.fetch()
+
+	r.polled, r.pollErr = true, err
+	if err != nil {
+		return "unreachable"
+	}
+
+	if r.etag != "" {
+		return r.etag
+	}
+
+	return _sha256.sum(b)
+}
+
+// readRemote fetches, verifies and parses the configuration served by r.
+//
+// The payload must be verified by a checksum in the X-Config-Checksum header,
+// unless checksums are disabled, and signed in the X-Config-Signature header
+// when trusted keys are configured, both in the formats of their files. When
+// nothing could be fetched yet, the server being unreachable, the cached
+// payload is used instead. The payload fetched by the last poll, if not read
+// yet, is used rather than fetched again, as is its error.
+func (p *Config) readRemote(r *remote) (*properties.Properties, layerState, error) {
+	state := layerState{filename: r.url}
+
+	r.mu.Lock()
+
+	fetched := r.body != nil
+
+	b, header, err := r.body, r.header, r.pollErr
+	if !r.polled {
+		b, header, err = r.fetch()
+	}
+
+	r.polled = false
+	r.mu.Unlock()
+
+	if err != nil {
+		if fetched || r.cache == "" {
+			return nil, state, fmt.Errorf("reading configuration: %v", err)
+		}
+
+		prop, state, cacheErr := p.read(r.cache)
+		if cacheErr != nil {
+			return nil, state, fmt.Errorf("reading configuration: %v, and from cache: %v", err, cacheErr)
+		}
+
+		return prop, state, nil
+	}
+
+	if state.checksum, state.sum, err = verifyHeader(b, r.url, header.Get(_checksumHeader)); err != nil {
+		return nil, state, fmt.Errorf("verifying configuration: %v", err)
+	}
+
+	sig := header.Get(_signatureHeader)
+	if len(p.trusted) > 0 {
+		if state.signer, err = checkSignature(b, []byte(sig), r.url, _signatureHeader+" header", p.trusted); err != nil {
+			return nil, state, fmt.Errorf("verifying configuration signature: %v", err)
+		}
+	}
+
+	prop, err := r.format(b)
+	if err != nil {
+		return nil, state, fmt.Errorf("loading configuration: %v", err)
+	}
+
+	if r.cache != "" {
+		// The cache is a fallback, failing to write it must not prevent from
+		// using a valid configuration.
+		r.save(b, state, sig) //nolint:errcheck
+	}
+
+	return prop, state, nil
+}
+
+// verifyHeader checks b, served at url, against the checksum in the
+// X-Config-Checksum header, which is either a bare MD5 digest or a digest
+// prefixed by its algorithm, as in "sha256:<digest>". It returns the name of
+// the algorithm used, which is empty when checksums are disabled, and the
+// verified digest.
+func verifyHeader(b []byte, url, value string) (string, string, error) {
+	if c := os.Getenv(_checksumEnabled); c == "false" {
+		return "", "", nil
+	}
+
+	if value == "" {
+		return "", "", fmt.Errorf("missing %s header for %s", _checksumHeader, url)
+	}
+
+	alg, expected, err := parseChecksum([]byte(value), _md5, "")
+	if err != nil {
+		return "", "", fmt.Errorf("parsing %s header: %v", _checksumHeader, err)
+	}
+
+	if err := checkSum(b, url, alg, expected); err != nil {
+		return "", "", err
+	}
+
+	return alg.name, expected, nil
+}
+
+// save caches b along with its checksum and signature files, so that it is
+// verified again when read from the cache.
+func (r *remote) save(b []byte, state layerState, sig string) error {
+	if err := os.MkdirAll(filepath.Dir(r.cache), 0o700); err != nil {
+		return err
+	}
+
+	files := map[string]string{r.cache: string(b), r.cache + _signatureExt: sig}
+	for _, a := range _algorithms {
+		files[a.sidecar(r.cache)] = ""
+		if a.name == state.checksum {
+			files[a.sidecar(r.cache)] = state.sum
+		}
+	}
+
+	for filename, content := range files {
+		if content == "" {
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			continue
+		}
+
+		if err := writeFile(filename, []byte(content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes filename atomically, through a temporary file renamed once
+// written.
+func writeFile(filename string, b []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
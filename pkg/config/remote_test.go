@@ -0,0 +1,286 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/md5" //nolint:gosec
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// configServer serves a configuration with its checksum and an ETag.
+type configServer struct {
+	mu       sync.Mutex
+	content  string
+	checksum string
+	sig      string
+	status   int
+	requests int
+	notMod   int
+	// polled, when set, is notified of requests, which wait for release.
+	polled  chan struct{}
+	release chan struct{}
+}
+
+func (s *configServer) set(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := md5.Sum([]byte(content)) //nolint:gosec
+	s.content, s.checksum = content, hex.EncodeToString(sum[:])
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.polled != nil {
+		select {
+		case s.polled <- struct{}{}:
+		default:
+		}
+
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	etag := `"` + s.checksum + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		s.notMod++
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Config-Checksum", s.checksum)
+
+	if s.sig != "" {
+		w.Header().Set("X-Config-Signature", s.sig)
+	}
+
+	w.Write([]byte(s.content)) //nolint:errcheck
+}
+
+func TestLoad_remote(t *testing.T) {
+	// Given
+	s := &configServer{}
+	s.set("db:\n  host: localhost\n")
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	url := server.URL + "/application.yaml"
+
+	t.Setenv("configFileName", url)
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	cfg, err := Load(WithHTTPClient(server.Client()), WithCacheDir(""))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "localhost", cfg.GetString("db.host", ""))
+	require.Equal(t, []string{url}, cfg.Layers())
+
+	source, ok := cfg.Source("db.host")
+	require.True(t, ok)
+	require.Equal(t, url, source)
+
+	// Unchanged configurations are not transferred again.
+	require.Equal(t, cfg.stamp, cfg.fingerprint())
+	require.NoError(t, cfg.Reload())
+	require.Equal(t, s.requests-1, s.notMod)
+
+	// Changes are noticed by polling.
+	s.set("db:\n  host: db.internal\n")
+	require.NotEqual(t, cfg.stamp, cfg.fingerprint())
+	require.NoError(t, cfg.Reload())
+	require.Equal(t, "db.internal", cfg.GetString("db.host", ""))
+}
+
+func TestWatch_remote(t *testing.T) {
+	// Given
+	s := &configServer{}
+	s.set("string=value\n")
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	t.Setenv("configFileName", server.URL+"/application.properties")
+	t.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load(WithHTTPClient(server.Client()), WithCacheDir(""))
+	require.NoError(t, err)
+	require.Equal(t, 1, s.requests)
+
+	s.set("string=changed\n")
+	s.polled, s.release = make(chan struct{}, 1), make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan int)
+	cfg.OnChange(func(_, newValues map[string]string) {
+		if newValues["string"] == "changed" {
+			s.mu.Lock()
+			changed <- s.requests
+			s.mu.Unlock()
+		}
+	})
+
+	go cfg.Watch(ctx, time.Millisecond, nil)
+
+	// When
+	<-s.polled
+
+	// Then
+	// Modifications are not blocked while polling.
+	require.NoError(t, cfg.Set("other", "value"))
+
+	close(s.release)
+
+	// A change costs a single request, the payload polled being reused.
+	require.Equal(t, 2, <-changed)
+	require.Equal(t, "changed", cfg.GetString("string", ""))
+}
+
+func TestLoad_remoteCache(t *testing.T) {
+	// Given
+	s := &configServer{}
+	s.set("string=value\n")
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	t.Setenv("configFileName", server.URL+"/application.properties")
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configCacheDir", dir)
+
+	_, err := Load(WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+
+	s.status = http.StatusServiceUnavailable
+	s.requests = 0
+
+	// When
+	cfg, err := Load(WithHTTPClient(server.Client()))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+	// The failed poll taking the fingerprint isn't retried by the read.
+	require.Equal(t, 1, s.requests)
+
+	// Reloads use the cache until the server is reachable.
+	require.NoError(t, cfg.Reload())
+	require.Equal(t, "value", cfg.GetString("string", ""))
+
+	// The cache is verified as well.
+	files, err := filepath.Glob(filepath.Join(dir, "*.properties"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.NoError(t, ioutil.WriteFile(files[0], []byte("string=tampered\n"), 0o600))
+
+	_, err = Load(WithHTTPClient(server.Client()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected status 503 Service Unavailable, and from cache: verifying configuration: md5 checksum mismatch")
+}
+
+func TestLoad_remoteErr(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name    string
+		server  func(s *configServer)
+		trusted []TrustedKey
+		err     string
+	}{
+		{
+			name:   "not found",
+			server: func(s *configServer) { s.status = http.StatusNotFound },
+			err:    "reading configuration: fetching $URL: unexpected status 404 Not Found",
+		},
+		{
+			name:   "missing checksum",
+			server: func(s *configServer) { s.checksum = "" },
+			err:    "verifying configuration: missing X-Config-Checksum header for $URL",
+		},
+		{
+			name:   "checksum mismatch",
+			server: func(s *configServer) { s.checksum = "sha256:" + _sha256.sum([]byte("other")) },
+			err:    "verifying configuration: sha256 checksum mismatch for $URL: expected " + _sha256.sum([]byte("other")) + ", got " + _sha256.sum([]byte("string=value\n")),
+		},
+		{
+			name:    "missing signature",
+			server:  func(s *configServer) {},
+			trusted: []TrustedKey{{ID: "release", Key: pub}},
+			err:     "verifying configuration signature: malformed signature in X-Config-Signature header",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			s := &configServer{}
+			s.set("string=value\n")
+			tc.server(s)
+
+			server := httptest.NewServer(s)
+			defer server.Close()
+
+			url := server.URL + "/application.properties"
+
+			t.Setenv("configFileName", url)
+			t.Setenv("checksumEnabled", "true")
+
+			// When
+			_, err := Load(WithHTTPClient(server.Client()), WithCacheDir(""), WithTrustedKeys(tc.trusted...))
+
+			// Then
+			require.EqualError(t, err, strings.ReplaceAll(tc.err, "$URL", url))
+		})
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		// Given
+		s := &configServer{}
+		s.set("string=value\n")
+		s.sig = string(Sign(priv, []byte("string=value\n")))
+
+		server := httptest.NewServer(s)
+		defer server.Close()
+
+		t.Setenv("configFileName", server.URL+"/application.properties")
+		t.Setenv("checksumEnabled", "true")
+
+		// When
+		cfg, err := Load(WithHTTPClient(server.Client()), WithCacheDir(""), WithTrustedKeys(TrustedKey{ID: "release", Key: pub}))
+
+		// Then
+		require.NoError(t, err)
+
+		signer, ok := cfg.SignedBy(server.URL + "/application.properties")
+		require.True(t, ok)
+		require.Equal(t, "release", signer)
+	})
+}
@@ -0,0 +1,398 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// _setSource is the source reported for the properties modified by Set.
+const _setSource = "set"
+
+// edit is a modification made by Set or Delete, kept until saved.
+type edit struct {
+	key     string
+	value   string
+	deleted bool
+}
+
+// apply makes the modification to the properties of s.
+func (e edit) apply(s *snapshot) {
+	if e.deleted {
+		s.prop.Delete(e.key)
+		delete(s.sources, e.key)
+
+		return
+	}
+
+	s.prop.Set(e.key, e.value) //nolint:errcheck // Set never fails when expansion is disabled.
+	s.sources[e.key] = _setSource
+}
+
+// clone returns a copy of s whose properties can be modified.
+func (s *snapshot) clone() *snapshot {
+	c := &snapshot{
+		prop:    newProperties(),
+		sources: make(map[string]string, len(s.sources)),
+		layers:  s.layers,
+		loaded:  s.loaded,
+	}
+
+	c.prop.Merge(s.prop)
+
+	for k, v := range s.sources {
+		c.sources[k] = v
+	}
+
+	return c
+}
+
+// Set sets the value of the property, calling the OnChange callbacks. The value
+// is written as is, references and encrypted values being resolved when read.
+// Lists are written with the helpers of package utils, such as
+// ConvertIntSliceToString.
+//
+// The OnChange callbacks are called once the property is set, and may use the
+// configuration, see OnChange.
+//
+// Modifications are kept over reloads, on top of the files of the
+// configuration, until they are written to its file by Save. An error is
+// returned, and the property left unchanged, when the resulting configuration
+// is invalid, for instance when a reference can't be resolved anymore, or when
+// the property comes from a scope file or a directory, which Save doesn't
+// write.
+func (p *Config) Set(key, value string) error {
+	if p.root != nil {
+		return p.root.Set(p.key(key), value)
+	}
+
+	if key == "" {
+		return errors.New("setting property: empty key")
+	}
+
+	return p.edit(edit{key: key, value: value})
+}
+
+// Delete removes the property, as Set does.
+func (p *Config) Delete(key string) error {
+	if p.root != nil {
+		return p.root.Delete(p.key(key))
+	}
+
+	return p.edit(edit{key: key, deleted: true})
+}
+
+func (p *Config) edit(e edit) error {
+	defer p.notify()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.snapshot()
+
+	if src, ok := s.sources[e.key]; ok && len(p.layers) > 0 && src != p.layers[0].filename && src != _setSource {
+		verb := "setting"
+		if e.deleted {
+			verb = "deleting"
+		}
+
+		return fmt.Errorf("%s property: key %s comes from %s, which Save doesn't write", verb, e.key, src)
+	}
+
+	s = s.clone()
+	e.apply(s)
+
+	if err := p.check(s); err != nil {
+		return err
+	}
+
+	p.edits = append(p.edits, e)
+	p.swap(s)
+
+	return nil
+}
+
+// Save writes the modifications made by Set and Delete to the configuration
+// file, which must be a Java properties file, not a scope file nor a directory.
+// Comments and the order of the keys are kept, edited properties being
+// rewritten in place and new ones appended.
+//
+// The file and its checksum files are each written atomically, through a
+// temporary file renamed once written, so that they are never read partially
+// written. As they are written one after the other, a configuration reading
+// them meanwhile may find the file along with the checksum of its previous
+// version, and reads them again once they stopped changing, see Reload. Other
+// readers may see such a mismatch. The MD5 checksum file is always written,
+// SHA-256 and SHA-512 ones only when they already exist. A signature file
+// isn't updated, signed files must be signed again, see SignFile.
+func (p *Config) Save() error {
+	if p.root != nil {
+		return p.root.Save()
+	}
+
+	if len(p.layers) == 0 {
+		return errors.New("saving configuration: not loaded from a file")
+	}
+
+	filename := p.layers[0].filename
+	if p.layers[0].remote != nil || !isProperties(filename) {
+		return fmt.Errorf("saving configuration: %s is not a properties file", filename)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.edits) == 0 {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("saving configuration: %v", err)
+	}
+
+	b, err = applyEdits(b, p.edits)
+	if err != nil {
+		return fmt.Errorf("saving configuration: %v", err)
+	}
+
+	if err := writeFile(filename, b); err != nil {
+		return fmt.Errorf("saving configuration: %v", err)
+	}
+
+	for _, a := range _algorithms {
+		if a.name != _md5.name {
+			if _, err := os.Stat(a.sidecar(filename)); err != nil {
+				continue
+			}
+		}
+
+		if err := writeFile(a.sidecar(filename), []byte(a.sum(b))); err != nil {
+			return fmt.Errorf("saving configuration: %v", err)
+		}
+	}
+
+	p.edits = nil
+
+	return nil
+}
+
+// applyEdits returns the properties file b with edits applied. The lines of
+// edited properties are rewritten, keeping their key and separator as
+// written, or removed when they were deleted. Properties that are not in the
+// file are appended, in the order they were first set.
+func applyEdits(b []byte, edits []edit) ([]byte, error) {
+	last := map[string]edit{}
+
+	var order []string
+
+	for _, e := range edits {
+		if _, ok := last[e.key]; !ok {
+			order = append(order, e.key)
+		}
+
+		last[e.key] = e
+	}
+
+	text := string(b)
+
+	newline := "\n"
+	if strings.Contains(text, "\r\n") {
+		newline = "\r\n"
+	}
+
+	lines, err := logicalLines(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+
+	written := map[string]bool{}
+
+	for _, l := range lines {
+		e, ok := last[l.key]
+		if l.key == "" || !ok {
+			out.WriteString(l.text)
+			continue
+		}
+
+		written[l.key] = true
+
+		if e.deleted {
+			continue
+		}
+
+		body := strings.TrimRight(l.text, "\r\n")
+		offset, separated := valueOffset(body)
+		out.WriteString(body[:offset])
+
+		if !separated {
+			out.WriteString("=")
+		}
+
+		out.WriteString(escapeValue(e.value))
+		out.WriteString(l.text[len(body):])
+	}
+
+	for _, k := range order {
+		e := last[k]
+		if e.deleted || written[k] {
+			continue
+		}
+
+		if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			out.WriteString(newline)
+		}
+
+		out.WriteString(escapeKey(k) + "=" + escapeValue(e.value) + newline)
+	}
+
+	return []byte(out.String()), nil
+}
+
+// propertyLine is a logical line of a properties file, made of physical lines
+// joined by trailing backslashes. key is empty for blank and comment lines.
+type propertyLine struct {
+	text string
+	key  string
+}
+
+// logicalLines splits text into logical lines, keeping their line endings.
+func logicalLines(text string) ([]propertyLine, error) {
+	var lines []propertyLine
+
+	physical := strings.SplitAfter(text, "\n")
+
+	for i := 0; i < len(physical); i++ {
+		line := physical[i]
+		if line == "" {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t\f")
+		if trimmed == "" || trimmed[0] == '\n' || trimmed[0] == '\r' || trimmed[0] == '#' || trimmed[0] == '!' {
+			lines = append(lines, propertyLine{text: line})
+			continue
+		}
+
+		for continued(line) && i+1 < len(physical) {
+			i++
+			line += physical[i]
+		}
+
+		prop, err := parseProperties([]byte(line))
+		if err != nil {
+			return nil, err
+		}
+
+		l := propertyLine{text: line}
+		if keys := prop.Keys(); len(keys) > 0 {
+			l.key = keys[0]
+		}
+
+		lines = append(lines, l)
+	}
+
+	return lines, nil
+}
+
+// continued reports whether the physical line ends with an odd number of
+// backslashes, continuing on the next line.
+func continued(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+
+	n := len(line) - len(strings.TrimRight(line, `\`))
+
+	return n%2 == 1
+}
+
+// valueOffset returns the offset of the value in the logical line, past its
+// key and separator, and whether there is a separator, a line holding only a
+// key having none.
+func valueOffset(line string) (int, bool) {
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' || c == '\f' }
+
+	i := 0
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+
+	for i < len(line) {
+		c := line[i]
+		if c == '\\' {
+			i += 2
+			continue
+		}
+
+		if c == '=' || c == ':' || isSpace(c) {
+			break
+		}
+
+		i++
+	}
+
+	separated := i < len(line)
+
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+
+	if i < len(line) && (line[i] == '=' || line[i] == ':') {
+		i++
+
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+	}
+
+	if i > len(line) {
+		return len(line), separated
+	}
+
+	return i, separated
+}
+
+var (
+	_keyEscaper = strings.NewReplacer(`\`, `\\`, " ", `\ `, "=", `\=`, ":", `\:`, "#", `\#`, "!", `\!`,
+		"\t", `\t`, "\n", `\n`, "\r", `\r`, "\f", `\f`)
+	_valueEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\f", `\f`)
+)
+
+// FormatProperties returns the properties of m in the Java properties format,
+// sorted by key, escaped so that reading them back gives m.
+func FormatProperties(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+
+	for _, k := range keys {
+		b.WriteString(escapeKey(k) + "=" + escapeValue(m[k]) + "\n")
+	}
+
+	return b.String()
+}
+
+// escapeKey escapes k to be written as a key of a properties file.
+func escapeKey(k string) string {
+	return _keyEscaper.Replace(k)
+}
+
+// escapeValue escapes v to be written as a value of a properties file, leading
+// spaces being otherwise ignored.
+func escapeValue(v string) string {
+	v = _valueEscaper.Replace(v)
+	if strings.HasPrefix(v, " ") {
+		v = `\` + v
+	}
+
+	return v
+}
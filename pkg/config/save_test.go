@@ -0,0 +1,267 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"db.host": "localhost", "db.url": "jdbc://${db.host}/x"})
+
+	var changes []map[string]string
+	cfg.OnChange(func(_, newValues map[string]string) {
+		changes = append(changes, newValues)
+	})
+
+	// When
+	require.NoError(t, cfg.Set("db.host", "db.internal"))
+	require.NoError(t, cfg.Sub("db").Set("pool.size", "10"))
+
+	// Then
+	require.Equal(t, "jdbc://db.internal/x", cfg.GetString("db.url", ""))
+	require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+	source, _ := cfg.Source("db.host")
+	require.Equal(t, "set", source)
+	require.Len(t, changes, 2)
+
+	require.EqualError(t, cfg.Delete("db.host"),
+		"interpolating configuration: key db.url: interpolating value: unresolved reference ${db.host}")
+	require.Equal(t, "db.internal", cfg.GetString("db.host", ""))
+
+	require.NoError(t, cfg.Delete("db.pool.size"))
+	require.False(t, cfg.Has("db.pool.size"))
+	require.Len(t, changes, 3)
+
+	require.EqualError(t, cfg.Set("", "x"), "setting property: empty key")
+}
+
+func TestSet_fromOnChange(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{"a": "1"})
+
+	var changes []map[string]string
+	cfg.OnChange(func(_, newValues map[string]string) {
+		changes = append(changes, newValues)
+	})
+	cfg.OnChange(func(_, newValues map[string]string) {
+		if newValues["a"] == "2" {
+			require.NoError(t, cfg.Set("b", newValues["a"]))
+			require.Error(t, cfg.Reload())
+		}
+	})
+
+	// When
+	err := cfg.Set("a", "2")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []map[string]string{
+		{"a": "2"},
+		{"a": "2", "b": "2"},
+	}, changes)
+}
+
+func TestSave(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "# Database\n"+
+		"db.host = localhost\n"+
+		"db.url=jdbc://${db.host}/x\n"+
+		"\n"+
+		"! Deprecated\n"+
+		"db.legacy : a, \\\n"+
+		"    b\n"+
+		"ids:1,2\n"+
+		"flag\n")
+	require.NoError(t, WriteChecksumFile(filename, "sha256"))
+
+	cfg, err := LoadFile(filename)
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.Set("db.host", " db.internal"))
+	require.NoError(t, cfg.Set("new key", "line1\nline2"))
+	require.NoError(t, cfg.Delete("db.legacy"))
+	require.NoError(t, cfg.Set("ids", "3"))
+	require.NoError(t, cfg.Set("flag", "true"))
+
+	// When
+	err = cfg.Save()
+
+	// Then
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "# Database\n"+
+		"db.host = \\ db.internal\n"+
+		"db.url=jdbc://${db.host}/x\n"+
+		"\n"+
+		"! Deprecated\n"+
+		"ids:3\n"+
+		"flag=true\n"+
+		"new\\ key=line1\\nline2\n", string(b))
+
+	saved, err := LoadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, cfg.GetAll(), saved.GetAll())
+
+	for _, a := range []algorithm{_md5, _sha256} {
+		sum, err := ioutil.ReadFile(a.sidecar(filename))
+		require.NoError(t, err)
+		require.Equal(t, a.sum(b), string(sum))
+	}
+
+	require.NoFileExists(t, _sha512.sidecar(filename))
+}
+
+func TestSave_keptOverReload(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "a=1\r\nb=2\r\n")
+
+	cfg, err := LoadFile(filename)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set("c", "3"))
+
+	// When
+	writeConfig(t, filename, "a=10\r\nb=2")
+	require.NoError(t, cfg.Reload())
+
+	// Then
+	require.Equal(t, map[string]string{"a": "10", "b": "2", "c": "3"}, cfg.GetAll())
+
+	require.NoError(t, cfg.Save())
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "a=10\r\nb=2\r\nc=3\r\n", string(b))
+
+	require.NoError(t, cfg.Reload())
+	source, _ := cfg.Source("c")
+	require.Equal(t, filename, source)
+}
+
+func TestSave_concurrentReload(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "application.properties")
+	writeConfig(t, filename, "n=0\n")
+
+	cfg, err := LoadFile(filename)
+	require.NoError(t, err)
+
+	reader, err := LoadFile(filename)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			if err := reader.Reload(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	// When
+	for i := 1; i <= 50; i++ {
+		require.NoError(t, cfg.Set("n", strconv.Itoa(i)))
+		require.NoError(t, cfg.Save())
+	}
+
+	close(done)
+
+	// Then
+	require.NoError(t, <-errs)
+
+	for _, name := range []string{filename, _md5.sidecar(filename)} {
+		info, err := os.Lstat(name)
+		require.NoError(t, err)
+		require.True(t, info.Mode().IsRegular())
+	}
+
+	require.NoError(t, reader.Reload())
+	require.Equal(t, 50, reader.GetInt("n", 0))
+}
+
+func TestSet_otherLayer(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "application.properties")
+	scoped := filepath.Join(dir, "application-prod.properties")
+	writeConfig(t, filename, "db.host=localhost\ndb.port=5432\n")
+	writeConfig(t, scoped, "db.host=db.prod\n")
+
+	secrets := filepath.Join(dir, "secrets")
+	writeMount(t, secrets, "1", map[string]string{"db.password": "s3cr3t"})
+
+	cfg, err := LoadFile(filename, WithScope("prod"), WithDir(secrets))
+	require.NoError(t, err)
+
+	// When
+	setErr := cfg.Set("db.host", "db.internal")
+	deleteErr := cfg.Sub("db").Delete("password")
+
+	// Then
+	require.EqualError(t, setErr,
+		"setting property: key db.host comes from "+scoped+", which Save doesn't write")
+	require.EqualError(t, deleteErr,
+		"deleting property: key db.password comes from "+filepath.Join(secrets, "db.password")+", which Save doesn't write")
+	require.Equal(t, "db.prod", cfg.GetString("db.host", ""))
+	require.Equal(t, "s3cr3t", cfg.GetString("db.password", ""))
+
+	require.NoError(t, cfg.Set("db.port", "5433"))
+	require.NoError(t, cfg.Set("db.port", "5434"))
+	require.NoError(t, cfg.Save())
+	require.NoError(t, cfg.Reload())
+	require.Equal(t, 5434, cfg.GetInt("db.port", 0))
+}
+
+func TestSave_err(t *testing.T) {
+	t.Setenv("checksumEnabled", "false")
+
+	require.EqualError(t, LoadMap(nil).Save(), "saving configuration: not loaded from a file")
+
+	cfg, err := LoadFile("testdata/nested.yaml")
+	require.NoError(t, err)
+	require.EqualError(t, cfg.Save(), "saving configuration: testdata/nested.yaml is not a properties file")
+}
+
+func TestFormatProperties(t *testing.T) {
+	// Given
+	m := map[string]string{
+		"motd":            "line 1\nline 2",
+		"padded":          "  x",
+		"key=with:seps":   `back\slash`,
+		"db.url":          "jdbc://${db.host}/x",
+		"key with spaces": "#not a comment",
+	}
+
+	// When
+	content := FormatProperties(m)
+
+	// Then
+	prop, err := parseProperties([]byte(content))
+	require.NoError(t, err)
+	require.Equal(t, m, prop.Map())
+	require.Equal(t, "db.url=jdbc://${db.host}/x\n", FormatProperties(map[string]string{"db.url": m["db.url"]}))
+}
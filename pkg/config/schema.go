@@ -0,0 +1,389 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// ErrUnknownKey is reported by Schema.Check for properties that are not
+// registered. Use errors.Is to check for it.
+var ErrUnknownKey = errors.New("unknown key")
+
+// Type is the type of a property registered in a Schema, named after the
+// getter reading it.
+type Type string
+
+// Types of the properties registered in a Schema.
+const (
+	TypeString         Type = "string"
+	TypeBool           Type = "bool"
+	TypeInt            Type = "int"
+	TypeUint           Type = "uint"
+	TypeFloat          Type = "float"
+	TypeDuration       Type = "duration"
+	TypeParsedDuration Type = "parsed-duration"
+	TypeByteSize       Type = "byte-size"
+	TypeURL            Type = "url"
+	TypeIP             Type = "ip"
+	TypeCIDR           Type = "cidr"
+	TypeRegexp         Type = "regexp"
+	TypeTime           Type = "time"
+	TypeStringSlice    Type = "list"
+	TypeIntSlice       Type = "int-list"
+	TypeFloatSlice     Type = "float-list"
+	TypeBoolSlice      Type = "bool-list"
+	TypeDurationSlice  Type = "duration-list"
+	TypeStringMap      Type = "map"
+	TypeJSON           Type = "json"
+)
+
+// _types check that a property can be read with the getter of a type.
+var _types = map[Type]func(r Reader, key string) error{
+	TypeString:         func(r Reader, key string) error { _, err := r.LookupString(key); return err },
+	TypeBool:           func(r Reader, key string) error { _, err := r.LookupBool(key); return err },
+	TypeInt:            func(r Reader, key string) error { _, err := r.LookupInt(key); return err },
+	TypeUint:           func(r Reader, key string) error { _, err := r.LookupUint(key); return err },
+	TypeFloat:          func(r Reader, key string) error { _, err := r.LookupFloat64(key); return err },
+	TypeDuration:       func(r Reader, key string) error { _, err := r.LookupDuration(key); return err },
+	TypeParsedDuration: func(r Reader, key string) error { _, err := r.LookupParsedDuration(key); return err },
+	TypeByteSize:       func(r Reader, key string) error { _, err := r.LookupByteSize(key); return err },
+	TypeURL:            func(r Reader, key string) error { _, err := r.LookupURL(key); return err },
+	TypeIP:             func(r Reader, key string) error { _, err := r.LookupIP(key); return err },
+	TypeCIDR:           func(r Reader, key string) error { _, err := r.LookupCIDR(key); return err },
+	TypeRegexp:         func(r Reader, key string) error { _, err := r.LookupRegexp(key); return err },
+	TypeTime:           func(r Reader, key string) error { _, err := r.LookupTime(key); return err },
+	TypeStringSlice:    func(r Reader, key string) error { _, err := r.LookupStringSlice(key); return err },
+	TypeIntSlice:       func(r Reader, key string) error { _, err := r.LookupIntSlice(key); return err },
+	TypeFloatSlice:     func(r Reader, key string) error { _, err := r.LookupFloatSlice(key); return err },
+	TypeBoolSlice:      func(r Reader, key string) error { _, err := r.LookupBoolSlice(key); return err },
+	TypeDurationSlice:  func(r Reader, key string) error { _, err := r.LookupDurationSlice(key); return err },
+	TypeStringMap:      func(r Reader, key string) error { _, err := r.LookupStringMap(key); return err },
+	TypeJSON: func(r Reader, key string) error {
+		v, err := r.LookupString(key)
+		if err != nil {
+			return err
+		}
+
+		var i interface{}
+		if err := json.Unmarshal([]byte(v), &i); err != nil {
+			if c, ok := r.(*Config); ok {
+				return c.parseError(key, v, err)
+			}
+
+			return malformed(key, v, err)
+		}
+
+		return nil
+	},
+}
+
+// Key describes a property a component reads.
+type Key struct {
+	// Name is the key of the property. A "*" segment matches any single
+	// segment, so that flags.*.enabled matches flags.search.enabled.
+	Name string
+	// Type is the type of the value, TypeString when empty.
+	Type Type
+	// Default is the value used when the property is missing, as written in
+	// a configuration file. It is only documented, getters still take their
+	// default value as argument.
+	Default string
+	// Description explains what the property configures.
+	Description string
+	// Required tells the property must be set, required keys having no
+	// default.
+	Required bool
+}
+
+// Schema registers the properties components read, so that unknown keys,
+// usually typos, and missing required keys are reported, see WithSchema, and
+// that the properties can be documented, see WriteMarkdown. It is safe for
+// concurrent use.
+type Schema struct {
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+// DefaultSchema is the schema components register their keys in, unless
+// given another one.
+var DefaultSchema = NewSchema()
+
+// NewSchema returns an empty schema.
+func NewSchema() *Schema {
+	return &Schema{keys: map[string]Key{}}
+}
+
+// WithSchema makes loads and reloads fail when the configuration holds keys
+// not registered in s or misses required ones, or when values can't be read
+// with the getter of their type.
+func WithSchema(s *Schema) Option {
+	return func(c *loadConfig) {
+		c.schema = s
+	}
+}
+
+// Register adds keys to the schema. Registering a key again is allowed as long
+// as it is described the same way.
+func (s *Schema) Register(keys ...Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	for _, k := range keys {
+		if k.Type == "" {
+			k.Type = TypeString
+		}
+
+		if err := k.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("registering key %s: %v", k.Name, err))
+			continue
+		}
+
+		if old, ok := s.keys[k.Name]; ok && old != k {
+			errs = append(errs, fmt.Errorf("registering key %s: already registered with another definition", k.Name))
+			continue
+		}
+
+		s.keys[k.Name] = k
+	}
+
+	return multierr.Combine(errs...)
+}
+
+// MustRegister adds keys to the schema as Register does, it panics if any of
+// them is invalid. It is meant to be called from init functions.
+func (s *Schema) MustRegister(keys ...Key) {
+	must(s.Register(keys...))
+}
+
+func (k Key) validate() error {
+	if k.Name == "" {
+		return errors.New("empty name")
+	}
+
+	check, ok := _types[k.Type]
+	if !ok {
+		return fmt.Errorf("unknown type %q", k.Type)
+	}
+
+	if k.Default == "" {
+		return nil
+	}
+
+	if k.Required {
+		return errors.New("required keys can't have a default")
+	}
+
+	// The name may hold wildcards, the default is checked under another key.
+	const key = "default"
+
+	if err := check(LoadMap(map[string]string{key: k.Default}), key); err != nil {
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) {
+			err = keyErr.Err
+		}
+
+		return fmt.Errorf("default %v", err)
+	}
+
+	return nil
+}
+
+// Keys returns the registered keys, sorted by name.
+func (s *Schema) Keys() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+
+	return keys
+}
+
+// Check reports every property of r that is not registered, suggesting the
+// closest registered key, every required key r misses and every value that
+// can't be read with the getter of its type. Errors are *KeyError wrapping
+// ErrUnknownKey, ErrNotFound or *ParseError. The deprecated keys of r, see
+// Alias, are checked as the keys they were renamed to.
+func (s *Schema) Check(r Reader) error {
+	keys := s.Keys()
+
+	var errs []error
+
+	for _, name := range r.Keys() {
+		k, ok := match(keys, name)
+		if newName, renamed := renamedTo(r, name); !ok && renamed {
+			k, ok = match(keys, newName)
+		}
+
+		if !ok {
+			err := ErrUnknownKey
+			if suggestion := closest(keys, name); suggestion != "" {
+				err = fmt.Errorf("%w, did you mean %s?", ErrUnknownKey, suggestion)
+			}
+
+			errs = append(errs, &KeyError{Key: name, Err: err})
+
+			continue
+		}
+
+		if err := _types[k.Type](r, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, k := range keys {
+		if k.Required && !strings.Contains(k.Name, "*") && !r.Has(k.Name) {
+			errs = append(errs, &KeyError{Key: k.Name, Err: ErrNotFound})
+		}
+	}
+
+	return multierr.Combine(errs...)
+}
+
+// renamer is implemented by the configurations that deprecate keys, see Alias.
+type renamer interface {
+	renamedTo(key string) (string, bool)
+}
+
+// renamedTo returns the new name of name when r is a configuration that
+// deprecates it.
+func renamedTo(r Reader, name string) (string, bool) {
+	if c, ok := r.(renamer); ok {
+		return c.renamedTo(name)
+	}
+
+	return "", false
+}
+
+// match returns the key whose name matches the property name.
+func match(keys []Key, name string) (Key, bool) {
+	segments := strings.Split(name, ".")
+
+	for _, k := range keys {
+		pattern := strings.Split(k.Name, ".")
+		if len(pattern) != len(segments) {
+			continue
+		}
+
+		matched := true
+		for i, p := range pattern {
+			if p != "*" && p != segments[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return k, true
+		}
+	}
+
+	return Key{}, false
+}
+
+// closest returns the registered name closest to name, provided it is close
+// enough for name to likely be a typo of it.
+func closest(keys []Key, name string) string {
+	var (
+		best     string
+		bestDist = len(name)/4 + 2
+	)
+
+	for _, k := range keys {
+		if strings.Contains(k.Name, "*") {
+			continue
+		}
+
+		if d := distance(k.Name, name); d < bestDist {
+			best, bestDist = k.Name, d
+		}
+	}
+
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b, the number of
+// single byte insertions, deletions and substitutions turning a into b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// WriteMarkdown writes the reference documentation of the registered keys to
+// w, as a Markdown table sorted by key.
+func (s *Schema) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("| Key | Type | Default | Required | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+
+	for _, k := range s.Keys() {
+		def := ""
+		if k.Default != "" {
+			def = "`" + escapeMarkdown(k.Default) + "`"
+		}
+
+		required := "no"
+		if k.Required {
+			required = "yes"
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+			escapeMarkdown(k.Name), k.Type, def, required, escapeMarkdown(k.Description))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// escapeMarkdown escapes the characters of v that would break a table cell.
+func escapeMarkdown(v string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(v)
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSchema(t *testing.T) *Schema {
+	s := NewSchema()
+	require.NoError(t, s.Register(
+		Key{Name: "db.url", Description: "JDBC URL of the database.", Required: true},
+		Key{Name: "db.pool.size", Type: TypeInt, Default: "10", Description: "Maximum number of connections."},
+		Key{Name: "db.timeout", Type: TypeParsedDuration, Default: "5s", Description: "Query timeout."},
+		Key{Name: "flags.*.enabled", Type: TypeBool, Description: "Whether the flag is on | off."},
+	))
+
+	return s
+}
+
+func TestSchema_Register_err(t *testing.T) {
+	tt := []struct {
+		name          string
+		key           Key
+		expectedError string
+	}{
+		{
+			name:          "empty name",
+			key:           Key{},
+			expectedError: "registering key : empty name",
+		},
+		{
+			name:          "unknown type",
+			key:           Key{Name: "a", Type: "complex"},
+			expectedError: `registering key a: unknown type "complex"`,
+		},
+		{
+			name:          "required with default",
+			key:           Key{Name: "a", Default: "x", Required: true},
+			expectedError: "registering key a: required keys can't have a default",
+		},
+		{
+			name:          "malformed default",
+			key:           Key{Name: "a", Type: TypeByteSize, Default: "ten"},
+			expectedError: `registering key a: default malformed value "ten": strconv.ParseFloat: parsing "": invalid syntax`,
+		},
+		{
+			name:          "conflicting definition",
+			key:           Key{Name: "db.pool.size", Type: TypeUint},
+			expectedError: "registering key db.pool.size: already registered with another definition",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			s := newTestSchema(t)
+
+			// When
+			err := s.Register(tc.key)
+
+			// Then
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestSchema_Register_again(t *testing.T) {
+	// Given
+	s := newTestSchema(t)
+
+	// When
+	err := s.Register(Key{Name: "db.pool.size", Type: TypeInt, Default: "10", Description: "Maximum number of connections."})
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, s.Keys(), 4)
+}
+
+func TestSchema_Check(t *testing.T) {
+	s := newTestSchema(t)
+
+	tt := []struct {
+		name           string
+		props          map[string]string
+		expectedErrors []string
+	}{
+		{
+			name: "valid",
+			props: map[string]string{
+				"db.url":               "jdbc://localhost/x",
+				"db.pool.size":         "20",
+				"flags.search.enabled": "true",
+			},
+		},
+		{
+			name: "typo",
+			props: map[string]string{
+				"db.url":       "jdbc://localhost/x",
+				"db.pool.szie": "20",
+			},
+			expectedErrors: []string{"key db.pool.szie: unknown key, did you mean db.pool.size?"},
+		},
+		{
+			name: "unknown key",
+			props: map[string]string{
+				"db.url":     "jdbc://localhost/x",
+				"cache.size": "20",
+				"flags.x":    "true",
+			},
+			expectedErrors: []string{"key cache.size: unknown key", "key flags.x: unknown key"},
+		},
+		{
+			name: "missing required key and malformed value",
+			props: map[string]string{
+				"db.timeout": "soon",
+			},
+			expectedErrors: []string{
+				`key db.timeout: malformed value "soon": time: invalid duration "soon"`,
+				"key db.url: not found",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			err := s.Check(LoadMap(tc.props))
+
+			// Then
+			if tc.expectedErrors == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, strings.Join(tc.expectedErrors, "; "))
+		})
+	}
+}
+
+func TestSchema_Check_errorsIs(t *testing.T) {
+	// Given
+	s := newTestSchema(t)
+
+	// When
+	err := s.Check(LoadMap(map[string]string{"db.pool.szie": "20"}))
+
+	// Then
+	require.True(t, errors.Is(err, ErrUnknownKey))
+	require.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestLoad_withSchema(t *testing.T) {
+	// Given
+	t.Setenv("checksumEnabled", "true")
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.url=jdbc://localhost/x\ndb.pool.size=10\n")
+
+	cfg, err := LoadFile(filename, WithSchema(newTestSchema(t)))
+	require.NoError(t, err)
+
+	// When
+	writeConfig(t, filename, "db.url=jdbc://localhost/x\ndb.pool.szie=20\n")
+	err = cfg.Reload()
+
+	// Then
+	require.EqualError(t, err, "checking configuration schema: key db.pool.szie: unknown key, did you mean db.pool.size?")
+	require.Equal(t, 10, cfg.GetInt("db.pool.size", 0))
+
+	_, err = LoadFile(filename, WithSchema(newTestSchema(t)))
+	require.EqualError(t, err, "checking configuration schema: key db.pool.szie: unknown key, did you mean db.pool.size?")
+}
+
+func TestSchema_WriteMarkdown(t *testing.T) {
+	// Given
+	s := newTestSchema(t)
+
+	var b strings.Builder
+
+	// When
+	err := s.WriteMarkdown(&b)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "| Key | Type | Default | Required | Description |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `db.pool.size` | int | `10` | no | Maximum number of connections. |\n"+
+		"| `db.timeout` | parsed-duration | `5s` | no | Query timeout. |\n"+
+		"| `db.url` | string |  | yes | JDBC URL of the database. |\n"+
+		"| `flags.*.enabled` | bool |  | no | Whether the flag is on \\| off. |\n", b.String())
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	_signatureExt        = ".sig"
+	_trustedKeysDir      = "configTrustedKeysDir"
+	_trustedKeys         = "configTrustedKeys"
+	_trustedKeySeparator = ":"
+)
+
+// TrustedKey is an ed25519 public key configuration files can be signed with.
+type TrustedKey struct {
+	// ID identifies the key, it is reported by SignedBy.
+	ID  string
+	Key ed25519.PublicKey
+}
+
+// WithTrustedKeys requires every configuration file to be signed by one of the
+// given keys. The signature is read from a file named after the configuration
+// file with the .sig extension, as written by SignFile. Loading or reloading a
+// file whose signature is missing or doesn't match any of the keys fails, as
+// does loading directories, see WithDir.
+//
+// When this option is not given, the keys are loaded from the directory named
+// by the configTrustedKeysDir environment variable, see LoadTrustedKeys, and
+// from the configTrustedKeys environment variable, which holds a
+// comma-separated list of keys in the "id:base64" or "base64" formats. When
+// none of them is set, signatures are not verified.
+func WithTrustedKeys(keys ...TrustedKey) Option {
+	return func(c *loadConfig) {
+		c.trusted = append([]TrustedKey{}, keys...)
+	}
+}
+
+// LoadTrustedKeys loads the public keys stored in dir, one per file, each one
+// being the base64 encoding of the raw key. Files are identified by their name
+// without extension, and hidden files are ignored, as well as the ..data
+// layout of Kubernetes secret mounts.
+func LoadTrustedKeys(dir string) ([]TrustedKey, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading trusted keys: %v", err)
+	}
+
+	var keys []TrustedKey
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		filename := filepath.Join(dir, e.Name())
+
+		// Entries may be symlinks, as in Kubernetes mounts, stat the target.
+		info, err := os.Stat(filename)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("loading trusted keys: %v", err)
+		}
+
+		key, err := ParsePublicKey(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("loading trusted key %s: %v", filename, err)
+		}
+
+		keys = append(keys, TrustedKey{
+			ID:  strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())),
+			Key: key,
+		})
+	}
+
+	return keys, nil
+}
+
+// trustedKeysFromEnv returns the keys configured in the environment.
+func trustedKeysFromEnv() ([]TrustedKey, error) {
+	var keys []TrustedKey
+
+	if dir := os.Getenv(_trustedKeysDir); dir != "" {
+		k, err := LoadTrustedKeys(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k...)
+	}
+
+	for _, s := range strings.Split(os.Getenv(_trustedKeys), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+
+		var id string
+		if i := strings.Index(s, _trustedKeySeparator); i >= 0 {
+			id, s = s[:i], s[i+1:]
+		}
+
+		key, err := ParsePublicKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("loading trusted key from %s: %v", _trustedKeys, err)
+		}
+
+		if id == "" {
+			id = fingerprint(key)
+		}
+
+		keys = append(keys, TrustedKey{ID: id, Key: key})
+	}
+
+	return keys, nil
+}
+
+// fingerprint returns a short identifier for key.
+func fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKey parses the base64 encoding of a raw ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size %d", len(b))
+	}
+
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey parses the base64 encoding of a raw ed25519 private key, or
+// of its seed.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(b) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	default:
+		return nil, fmt.Errorf("invalid private key size %d", len(b))
+	}
+}
+
+// Sign returns the signature of b in the format expected in .sig files: its
+// base64 encoding followed by a new line.
+func Sign(key ed25519.PrivateKey, b []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, b)) + "\n")
+}
+
+// SignFile signs the given configuration file, writing its signature next to
+// it in a file with the .sig extension.
+func SignFile(filename string, key ed25519.PrivateKey) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename+_signatureExt, Sign(key, b), 0o644) //nolint:gosec
+}
+
+// verifySignature checks b, the contents of filename, against its .sig file,
+// returning the ID of the trusted key that signed it.
+func verifySignature(b []byte, filename string, keys []TrustedKey) (string, error) {
+	content, err := ioutil.ReadFile(filename + _signatureExt)
+	if err != nil {
+		return "", err
+	}
+
+	return checkSignature(b, content, filename, filename+_signatureExt, keys)
+}
+
+// checkSignature checks b, the contents of name, against the signature in the
+// format of .sig files read from source.
+func checkSignature(b, content []byte, name, source string, keys []TrustedKey) (string, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed signature in %s", source)
+	}
+
+	for _, k := range keys {
+		if ed25519.Verify(k.Key, b, sig) {
+			return k.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("the file %s is not signed by any trusted key", name)
+}
+
+// SignedBy returns the ID of the trusted key that signed the given layer of
+// the configuration, see Layers. It returns false when signatures are not
+// verified or the file is not part of the configuration.
+func (p *Config) SignedBy(filename string) (string, bool) {
+	for _, l := range p.snapshot().layers {
+		if l.filename == filename && l.signer != "" {
+			return l.signer, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_signature(t *testing.T) {
+	// Given
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "string=value\n")
+	require.NoError(t, SignFile(filename, priv))
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+
+	// When
+	cfg, err := Load(WithTrustedKeys(TrustedKey{ID: "release", Key: pub}))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+
+	signer, ok := cfg.SignedBy(filename)
+	require.True(t, ok)
+	require.Equal(t, "release", signer)
+}
+
+func TestLoad_signatureFromEnv(t *testing.T) {
+	// Given
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "application.properties")
+	writeConfig(t, filename, "string=value\n")
+	require.NoError(t, SignFile(filename, priv))
+
+	keysDir := filepath.Join(dir, "keys")
+	require.NoError(t, os.Mkdir(keysDir, 0o700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(keysDir, "other.pub"), []byte(base64.StdEncoding.EncodeToString(otherPub)+"\n"), 0o600))
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+	t.Setenv("configTrustedKeysDir", keysDir)
+	t.Setenv("configTrustedKeys", base64.StdEncoding.EncodeToString(pub))
+
+	// When
+	cfg, err := Load()
+
+	// Then
+	require.NoError(t, err)
+
+	signer, ok := cfg.SignedBy(filename)
+	require.True(t, ok)
+	require.Equal(t, fingerprint(pub), signer)
+}
+
+func TestLoad_signatureErr(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name          string
+		sign          func(t *testing.T, filename string)
+		expectedError string
+	}{
+		{
+			name:          "missing signature",
+			sign:          func(t *testing.T, filename string) {},
+			expectedError: "verifying configuration signature: open %s.sig: no such file or directory",
+		},
+		{
+			name: "malformed signature",
+			sign: func(t *testing.T, filename string) {
+				require.NoError(t, ioutil.WriteFile(filename+".sig", []byte("not a signature"), 0o600))
+			},
+			expectedError: "verifying configuration signature: malformed signature in %s.sig",
+		},
+		{
+			name: "untrusted key",
+			sign: func(t *testing.T, filename string) {
+				require.NoError(t, SignFile(filename, otherPriv))
+			},
+			expectedError: "verifying configuration signature: the file %s is not signed by any trusted key",
+		},
+		{
+			name: "tampered file",
+			sign: func(t *testing.T, filename string) {
+				require.NoError(t, SignFile(filename, priv))
+				writeConfig(t, filename, "string=tampered\n")
+			},
+			expectedError: "verifying configuration signature: the file %s is not signed by any trusted key",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			filename := filepath.Join(t.TempDir(), "application.properties")
+			writeConfig(t, filename, "string=value\n")
+			tc.sign(t, filename)
+
+			t.Setenv("configFileName", filename)
+			t.Setenv("checksumEnabled", "true")
+
+			// When
+			_, err := Load(WithTrustedKeys(TrustedKey{ID: "release", Key: pub}))
+
+			// Then
+			require.EqualError(t, err, strings.ReplaceAll(tc.expectedError, "%s", filename))
+		})
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, err := ParsePrivateKey(base64.StdEncoding.EncodeToString(priv))
+	require.NoError(t, err)
+	require.Equal(t, priv, key)
+
+	key, err = ParsePrivateKey(base64.StdEncoding.EncodeToString(priv.Seed()))
+	require.NoError(t, err)
+	require.Equal(t, priv, key)
+
+	parsed, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub) + "\n")
+	require.NoError(t, err)
+	require.Equal(t, pub, parsed)
+
+	_, err = ParsePrivateKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.EqualError(t, err, "invalid private key size 5")
+
+	_, err = ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.EqualError(t, err, "invalid public key size 5")
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sub returns a view of the properties whose keys start with the given prefix
+// followed by a dot, the view's keys dropping both. For instance,
+// cfg.Sub("db").GetInt("pool.size", 10) reads db.pool.size. Views can be
+// nested and are live: they see reloads of the configuration they come from.
+//
+// References in values and environment variable overrides still use the full
+// keys, as they are written outside of the view.
+func (p *Config) Sub(prefix string) *Config {
+	prefix = strings.TrimSuffix(prefix, ".")
+	if prefix == "" {
+		return p
+	}
+
+	return &Config{
+		root:          p.base(),
+		prefix:        p.prefix + prefix + ".",
+		env:           p.env,
+		encryptionKey: p.encryptionKey,
+		keepEncrypted: p.keepEncrypted,
+	}
+}
+
+// base returns the configuration a view reads from, or p itself when it is not
+// a view.
+func (p *Config) base() *Config {
+	if p.root != nil {
+		return p.root
+	}
+
+	return p
+}
+
+// key returns the full key of the given key of the view.
+func (p *Config) key(key string) string {
+	return p.prefix + key
+}
+
+// Keys returns the keys of the configuration, sorted. Environment variable
+// overrides don't add keys.
+func (p *Config) Keys() []string {
+	var keys []string
+
+	for _, k := range p.props().Keys() {
+		if strings.HasPrefix(k, p.prefix) {
+			keys = append(keys, strings.TrimPrefix(k, p.prefix))
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Has reports whether the property exists.
+func (p *Config) Has(key string) bool {
+	_, ok := p.Raw(key)
+	return ok
+}
+
+// Range calls fn sequentially, in key order, for each property whose key
+// starts with prefix. Values are resolved as by GetAll. If fn returns false,
+// Range stops the iteration.
+func (p *Config) Range(prefix string, fn func(key, value string) bool) {
+	for _, k := range p.Keys() {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		if !fn(k, p.value(k)) {
+			return
+		}
+	}
+}
+
+// value returns the value of the existing property key, or its raw value when
+// it can't be resolved.
+func (p *Config) value(key string) string {
+	if v, err := p.find(p.snapshot(), key); err == nil {
+		return v
+	}
+
+	v, _ := p.Raw(key)
+
+	return v
+}
+
+// trim returns the properties of m belonging to the view, with their keys
+// relative to it.
+func (p *Config) trim(m map[string]string) map[string]string {
+	if p.prefix == "" {
+		return m
+	}
+
+	trimmed := map[string]string{}
+
+	for k, v := range m {
+		if strings.HasPrefix(k, p.prefix) {
+			trimmed[strings.TrimPrefix(k, p.prefix)] = v
+		}
+	}
+
+	return trimmed
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSub(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{
+		"db.host":         "localhost",
+		"db.pool.size":    "10",
+		"db.pool.timeout": "1s",
+		"db.url":          "jdbc://${db.host}/x",
+		"dbx.ignored":     "true",
+		"server.port":     "8080",
+	})
+
+	// When
+	db := cfg.Sub("db")
+
+	// Then
+	require.Equal(t, []string{"host", "pool.size", "pool.timeout", "url"}, db.Keys())
+	require.Equal(t, 10, db.GetInt("pool.size", 0))
+	require.Equal(t, "jdbc://localhost/x", db.GetString("url", ""))
+	require.True(t, db.Has("host"))
+	require.False(t, db.Has("server.port"))
+	require.Equal(t, map[string]string{
+		"host":         "localhost",
+		"pool.size":    "10",
+		"pool.timeout": "1s",
+		"url":          "jdbc://localhost/x",
+	}, db.GetAll())
+
+	pool := db.Sub("pool.")
+	require.Equal(t, []string{"size", "timeout"}, pool.Keys())
+	require.Equal(t, 10, pool.MustInt("size"))
+
+	_, err := pool.LookupInt("missing")
+	require.EqualError(t, err, "key missing: not found")
+
+	source, ok := pool.Source("size")
+	require.True(t, ok)
+	require.Equal(t, "map", source)
+}
+
+func TestRange(t *testing.T) {
+	// Given
+	cfg := LoadMap(map[string]string{
+		"db.host":      "localhost",
+		"db.pool.size": "10",
+		"db.url":       "jdbc://${db.host}/x",
+		"server.port":  "8080",
+	})
+
+	// When
+	got := map[string]string{}
+	cfg.Range("db.", func(key, value string) bool {
+		got[key] = value
+		return true
+	})
+
+	var first []string
+	cfg.Range("", func(key, value string) bool {
+		first = append(first, key)
+		return false
+	})
+
+	// Then
+	require.Equal(t, map[string]string{
+		"db.host":      "localhost",
+		"db.pool.size": "10",
+		"db.url":       "jdbc://localhost/x",
+	}, got)
+	require.Equal(t, []string{"db.host"}, first)
+}
+
+func TestSub_reload(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "db.pool.size=10\nserver.port=8080\n")
+
+	t.Setenv("configFileName", filename)
+	t.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	db := cfg.Sub("db")
+
+	var calls []map[string]string
+	db.OnChange(func(oldValues, newValues map[string]string) {
+		calls = append(calls, oldValues, newValues)
+	})
+
+	// When
+	writeConfig(t, filename, "db.pool.size=10\nserver.port=9090\n")
+	require.NoError(t, db.Reload())
+
+	writeConfig(t, filename, "db.pool.size=20\nserver.port=9090\n")
+	require.NoError(t, db.Reload())
+
+	// Then
+	require.Equal(t, 20, db.GetInt("pool.size", 0))
+	require.Equal(t, []map[string]string{{"pool.size": "10"}, {"pool.size": "20"}}, calls)
+}
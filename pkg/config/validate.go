@@ -0,0 +1,211 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const _validateTag = "validate"
+
+// rule is a single validation rule parsed from a "validate" tag.
+type rule struct {
+	name  string
+	param string
+}
+
+// parseRules parses the comma-separated rules of a "validate" tag. As regular
+// expressions may contain commas, the pattern rule consumes the rest of the
+// tag and must therefore be the last one.
+func parseRules(tag string) []rule {
+	var rules []rule
+
+	for tag != "" {
+		var r string
+		if strings.HasPrefix(tag, "pattern=") {
+			r, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			r, tag = tag[:i], tag[i+1:]
+		} else {
+			r, tag = tag, ""
+		}
+
+		name, param, _ := cut(strings.TrimSpace(r), "=")
+		if name != "" {
+			rules = append(rules, rule{name: name, param: param})
+		}
+	}
+
+	return rules
+}
+
+// validateField checks field against the rules of its "validate" tag. Every
+// violated rule is reported. When the property is not present only the
+// required rule is checked.
+func validateField(f reflect.StructField, field reflect.Value, present bool) []error {
+	var errs []error
+
+	for _, r := range parseRules(f.Tag.Get(_validateTag)) {
+		if r.name == "required" {
+			if !present {
+				errs = append(errs, errors.New("is required"))
+			}
+
+			continue
+		}
+
+		if !present {
+			continue
+		}
+
+		if err := r.check(field); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// violation reports a value that violates a validation rule.
+type violation struct {
+	rule string
+	got  string
+}
+
+func violated(rule, got string) error {
+	return &violation{rule: rule, got: got}
+}
+
+func (v *violation) Error() string {
+	return fmt.Sprintf("%s, got %s", v.rule, v.got)
+}
+
+func (r rule) check(field reflect.Value) error {
+	switch r.name {
+	case "min":
+		return r.checkBound(field, func(c int) bool { return c >= 0 }, "at least")
+	case "max":
+		return r.checkBound(field, func(c int) bool { return c <= 0 }, "at most")
+	case "oneof":
+		return r.checkEach(field, func(v string) error {
+			for _, o := range strings.Fields(r.param) {
+				if v == o {
+					return nil
+				}
+			}
+
+			return violated(fmt.Sprintf("must be one of [%s]", r.param), strconv.Quote(v))
+		})
+	case "pattern":
+		re, err := regexp.Compile(r.param)
+		if err != nil {
+			return fmt.Errorf("invalid pattern rule: %v", err)
+		}
+
+		return r.checkEach(field, func(v string) error {
+			if !re.MatchString(v) {
+				return violated("must match "+r.param, strconv.Quote(v))
+			}
+
+			return nil
+		})
+	case "nonempty":
+		switch field.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+			if field.Len() == 0 {
+				return errors.New("must not be empty")
+			}
+
+			return nil
+		default:
+			return fmt.Errorf("nonempty rule does not apply to %s", field.Type())
+		}
+	default:
+		return fmt.Errorf("unknown validation rule %q", r.name)
+	}
+}
+
+// checkBound compares field against the rule parameter, the comparison result
+// c being negative, zero or positive when the field is lower, equal or greater
+// than the parameter. Strings, slices and maps are compared by length.
+func (r rule) checkBound(field reflect.Value, ok func(c int) bool, desc string) error {
+	if field.Type() == _durationType {
+		bound, err := parseDuration(r.param)
+		if err != nil {
+			return fmt.Errorf("invalid %s rule: %v", r.name, err)
+		}
+
+		if d := field.Int(); !ok(compare(float64(d), float64(bound))) {
+			return violated(fmt.Sprintf("must be %s %s", desc, r.param), fmt.Sprint(field.Interface()))
+		}
+
+		return nil
+	}
+
+	bound, err := strconv.ParseFloat(r.param, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s rule: %v", r.name, err)
+	}
+
+	switch field.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		if !ok(compare(float64(field.Len()), bound)) {
+			return violated(fmt.Sprintf("length must be %s %s", desc, r.param), strconv.Itoa(field.Len()))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !ok(compare(float64(field.Int()), bound)) {
+			return violated(fmt.Sprintf("must be %s %s", desc, r.param), strconv.FormatInt(field.Int(), 10))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !ok(compare(float64(field.Uint()), bound)) {
+			return violated(fmt.Sprintf("must be %s %s", desc, r.param), strconv.FormatUint(field.Uint(), 10))
+		}
+	case reflect.Float32, reflect.Float64:
+		if !ok(compare(field.Float(), bound)) {
+			return violated(fmt.Sprintf("must be %s %s", desc, r.param), fmt.Sprint(field.Float()))
+		}
+	default:
+		return fmt.Errorf("%s rule does not apply to %s", r.name, field.Type())
+	}
+
+	return nil
+}
+
+// checkEach calls fn with the string representation of field or, for slices,
+// of each of its elements.
+func (r rule) checkEach(field reflect.Value, fn func(v string) error) error {
+	if field.Kind() != reflect.Slice {
+		return fn(fmt.Sprint(field.Interface()))
+	}
+
+	for i := 0; i < field.Len(); i++ {
+		if err := fn(fmt.Sprint(field.Index(i).Interface())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// cut slices s around the first instance of sep, see strings.Cut.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
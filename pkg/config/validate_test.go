@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+func TestBind_validate(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	var s struct {
+		Int        int           `config:"int" validate:"required,min=1,max=10"`
+		Float      float64       `config:"float" validate:"min=9.12,max=9.12"`
+		Duration   time.Duration `config:"format.duration" validate:"min=1us,max=1ms"`
+		String     string        `config:"string" validate:"oneof=value other,pattern=^[a-z]{1,5}$"`
+		StringList []string      `config:"string.list" validate:"nonempty,min=3,pattern=^[a-c][0-9](,a2)?$"`
+		Optional   int           `config:"non-existent-value" validate:"min=1"`
+		Defaulted  int           `config:"non-existent-value" default:"5" validate:"required,min=1"`
+	}
+
+	// When
+	err = cfg.Bind(&s)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 9, s.Int)
+	require.Equal(t, 0, s.Optional)
+	require.Equal(t, 5, s.Defaulted)
+}
+
+func TestBind_validateErrors(t *testing.T) {
+	// Given
+	t.Setenv("configFileName", "testdata/valid.properties")
+	t.Setenv("checksumEnabled", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	var s struct {
+		Int         int           `config:"int" validate:"min=10"`
+		Uint        uint          `config:"int" validate:"max=1"`
+		Float       float64       `config:"float" validate:"max=1.5"`
+		Duration    time.Duration `config:"format.duration" validate:"min=1s"`
+		String      string        `config:"string" validate:"oneof=a b"`
+		Pattern     string        `config:"string" validate:"pattern=^[0-9]+$"`
+		Length      string        `config:"string" validate:"max=2"`
+		IntList     []int         `config:"int.list" validate:"oneof=10 15"`
+		InvalidList []int         `config:"int.invalid.list"`
+		Required    string        `config:"db.host" validate:"required"`
+		Empty       []string      `config:"empty.list" default:"" validate:"nonempty"`
+		Unknown     string        `config:"string" validate:"unique"`
+	}
+
+	// When
+	err = cfg.Bind(&s)
+
+	// Then
+	require.Error(t, err)
+
+	var messages []string
+	for _, e := range multierr.Errors(err) {
+		var keyErr *KeyError
+		require.True(t, errors.As(e, &keyErr))
+		messages = append(messages, e.Error())
+	}
+
+	require.Equal(t, []string{
+		"key int: must be at least 10, got 9",
+		"key int: must be at most 1, got 9",
+		"key float: must be at most 1.5, got 9.12",
+		"key format.duration: must be at least 1s, got 91.218µs",
+		`key string: must be one of [a b], got "value"`,
+		`key string: must match ^[0-9]+$, got "value"`,
+		"key string: length must be at most 2, got 5",
+		`key int.list: must be one of [10 15], got "90"`,
+		`key int.invalid.list: malformed value "10,15,a": strconv.Atoi: parsing "a": invalid syntax`,
+		"key db.host: is required",
+		"key empty.list: must not be empty",
+		`key string: unknown validation rule "unique"`,
+	}, messages)
+}
+
+func TestParseRules(t *testing.T) {
+	rules := parseRules("required, min=1,oneof=a b,pattern=^(a|b),c$")
+
+	require.Equal(t, []rule{
+		{name: "required"},
+		{name: "min", param: "1"},
+		{name: "oneof", param: "a b"},
+		{name: "pattern", param: "^(a|b),c$"},
+	}, rules)
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ChangeFunc is called after a reload modified the configuration. It receives
+// the properties in use before and after the reload.
+type ChangeFunc func(oldValues, newValues map[string]string)
+
+// OnChange registers fn to be called every time a reload, Set or Delete
+// modifies the configuration. Callbacks are called sequentially, in
+// registration order and in the order of the changes, from the goroutine that
+// made the change, or from the one calling them for an earlier change when
+// they are already being called. They may use the configuration, including
+// Set, Delete, Reload, Save and OnChange, whose changes are then notified
+// once the current one is.
+//
+// Callbacks registered on a view created by Sub receive the properties of the
+// view, and are only called when they changed.
+func (p *Config) OnChange(fn ChangeFunc) {
+	if p.root != nil {
+		p.root.OnChange(func(oldValues, newValues map[string]string) {
+			oldValues, newValues = p.trim(oldValues), p.trim(newValues)
+			if !reflect.DeepEqual(oldValues, newValues) {
+				fn(oldValues, newValues)
+			}
+		})
+
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.listeners = append(p.listeners, fn)
+}
+
+// Reload reads the configuration files again and replaces the properties in use
+// if they are valid. Concurrent getters are safe to use while reloading, they see
+// either the old or the new properties but never a mix of both.
+//
+// If any file can't be read or fails its checksum verification an error is
+// returned and the last good properties are kept.
+func (p *Config) Reload() error {
+	if p.root != nil {
+		return p.root.Reload()
+	}
+
+	if len(p.layers) == 0 {
+		return errors.New("reloading configuration: not loaded from a file")
+	}
+
+	return p.reload(p.fingerprint())
+}
+
+// reload reads the configuration files again, as Reload does, stamp being their
+// fingerprint taken beforehand. It is taken without holding p.mu, as polling a
+// configuration served over HTTP may take a while.
+func (p *Config) reload(stamp string) error {
+	defer p.notify()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stamp = stamp
+
+	s, err := p.readLayers()
+	if err != nil {
+		return err
+	}
+
+	p.swap(s)
+
+	return nil
+}
+
+// change is a modification of the properties the OnChange callbacks are
+// called with.
+type change struct {
+	oldValues, newValues map[string]string
+}
+
+// swap replaces the properties in use by the ones of s, queuing a change for
+// the OnChange callbacks if they differ. p.mu must be held, and notify called
+// once it is released.
+func (p *Config) swap(s *snapshot) {
+	oldValues := p.props().Map()
+	newValues := s.prop.Map()
+
+	p.state.Store(s)
+
+	if reflect.DeepEqual(oldValues, newValues) {
+		return
+	}
+
+	p.changes = append(p.changes, change{oldValues: oldValues, newValues: newValues})
+}
+
+// notify calls the OnChange callbacks with the queued changes, without holding
+// p.mu so that they may use the configuration. Changes queued meanwhile, by the
+// callbacks or by other goroutines, are notified by the goroutine already
+// calling the callbacks, so that they are never called concurrently.
+func (p *Config) notify() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.notifying {
+		return
+	}
+
+	p.notifying = true
+	defer func() { p.notifying = false }()
+
+	for len(p.changes) > 0 {
+		c := p.changes[0]
+		p.changes = p.changes[1:]
+
+		p.call(p.listeners, c)
+	}
+}
+
+// call calls the listeners with the change c, releasing p.mu meanwhile.
+func (p *Config) call(listeners []ChangeFunc, c change) {
+	p.mu.Unlock()
+	defer p.mu.Lock()
+
+	for _, fn := range listeners {
+		fn(c.oldValues, c.newValues)
+	}
+}
+
+// Watch checks the configuration files and their checksum files every interval
+// and reloads the configuration when any of them changed. Configurations
+// served over HTTP are polled, see Load. It blocks until ctx is done.
+//
+// Reload failures are reported to errFn, when not nil, and the last good
+// properties are kept until a valid configuration is found.
+func (p *Config) Watch(ctx context.Context, interval time.Duration, errFn func(error)) {
+	p = p.base()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stamp := p.fingerprint()
+
+		p.mu.Lock()
+		changed := stamp != p.stamp
+		p.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+
+		if err := p.reload(stamp); err != nil && errFn != nil {
+			errFn(err)
+		}
+	}
+}
+
+// fingerprint summarizes the state of the files the configuration depends on,
+// the returned value changes whenever any of them is modified. It is taken
+// before reading the files, so that changes made while reading are noticed by
+// the next check.
+func (p *Config) fingerprint() string {
+	var s string
+
+	var filenames []string
+	for _, l := range p.layers {
+		if l.remote != nil {
+			s += fmt.Sprintf("%s:%s;", l.filename, l.remote.poll())
+			continue
+		}
+
+		if l.dir {
+			// Listing the directory notices added and removed properties.
+			files, _ := dirFiles(l.filename)
+
+			var names []string
+			for _, filename := range files {
+				names = append(names, filename)
+			}
+
+			sort.Strings(names)
+			filenames = append(filenames, names...)
+
+			continue
+		}
+
+		filenames = append(filenames, checkedFiles(l.filename)...)
+	}
+
+	return s + stampFiles(filenames)
+}
+
+// checkedFiles returns the configuration file filename along with the files it
+// is verified with, whether they exist or not.
+func checkedFiles(filename string) []string {
+	filenames := []string{filename}
+	for _, a := range _algorithms {
+		filenames = append(filenames, a.sidecar(filename))
+	}
+
+	return append(filenames, filename+_signatureExt)
+}
+
+// stampFiles returns a value that changes whenever any of the files is
+// modified, created or removed.
+func stampFiles(filenames []string) string {
+	var s string
+
+	for _, filename := range filenames {
+		info, err := os.Stat(filename)
+		if err != nil {
+			s += fmt.Sprintf("%s:missing;", filename)
+			continue
+		}
+
+		s += fmt.Sprintf("%s:%d:%d;", filename, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return s
+}
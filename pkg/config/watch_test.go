@@ -0,0 +1,157 @@
+package config
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "string=value\nint=9\n")
+	os.Setenv("configFileName", filename)
+	os.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	var oldValues, newValues map[string]string
+	cfg.OnChange(func(o, n map[string]string) {
+		oldValues, newValues = o, n
+	})
+
+	// When
+	writeConfig(t, filename, "string=other\nint=10\n")
+	err = cfg.Reload()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "other", cfg.GetString("string", ""))
+	require.Equal(t, 10, cfg.GetInt("int", 0))
+	require.Equal(t, map[string]string{"string": "value", "int": "9"}, oldValues)
+	require.Equal(t, map[string]string{"string": "other", "int": "10"}, newValues)
+}
+
+func TestReload_invalidChecksum(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "string=value\n")
+	os.Setenv("configFileName", filename)
+	os.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	called := false
+	cfg.OnChange(func(_, _ map[string]string) {
+		called = true
+	})
+
+	// When
+	require.NoError(t, ioutil.WriteFile(filename, []byte("string=tampered\n"), 0o600))
+	err = cfg.Reload()
+
+	// Then
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "verifying configuration: md5 checksum mismatch for "+filename)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+	require.False(t, called)
+}
+
+func TestReload_unchanged(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "string=value\n")
+	os.Setenv("configFileName", filename)
+	os.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	called := false
+	cfg.OnChange(func(_, _ map[string]string) {
+		called = true
+	})
+
+	// When
+	err = cfg.Reload()
+
+	// Then
+	require.NoError(t, err)
+	require.False(t, called)
+}
+
+func TestWatch(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "application.properties")
+	writeConfig(t, filename, "string=value\n")
+	os.Setenv("configFileName", filename)
+	os.Setenv("checksumEnabled", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	changed := make(chan map[string]string, 1)
+	cfg.OnChange(func(_, n map[string]string) {
+		changed <- n
+	})
+
+	var mu sync.Mutex
+	var errs []error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cfg.Watch(ctx, 10*time.Millisecond, func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		})
+	}()
+
+	// When
+	require.NoError(t, ioutil.WriteFile(filename, []byte("string=tampered-value\n"), 0o600))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, "value", cfg.GetString("string", ""))
+
+	writeConfig(t, filename, "string=new-value\n")
+
+	// Then
+	select {
+	case n := <-changed:
+		require.Equal(t, map[string]string{"string": "new-value"}, n)
+	case <-time.After(time.Second):
+		t.Fatal("expected configuration to be reloaded")
+	}
+	require.Equal(t, "new-value", cfg.GetString("string", ""))
+
+	cancel()
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	require.Contains(t, errs[0].Error(), "verifying configuration: md5 checksum mismatch for "+filename)
+}
+
+func writeConfig(t *testing.T, filename, content string) {
+	t.Helper()
+
+	sum := md5.Sum([]byte(content)) //nolint:gosec
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0o600))
+	require.NoError(t, ioutil.WriteFile(filename+".md5", []byte(hex.EncodeToString(sum[:])), 0o600))
+}
@@ -0,0 +1,186 @@
+// Package flags evaluates feature flags defined in the configuration.
+//
+// A flag called new-checkout is defined by the properties under
+// flags.new-checkout:
+//
+//	# Turns the flag on or off for everyone, it is off when missing.
+//	flags.new-checkout.enabled=true
+//	# Enables the flag for the given percentage of the subjects, 100 when
+//	# missing. Subjects are picked by hashing their ID, so that a subject
+//	# consistently gets the same result.
+//	flags.new-checkout.percentage=25
+//	# Subjects the flag is always enabled or disabled for, as long as it is on.
+//	flags.new-checkout.allow=user-1,user-2
+//	flags.new-checkout.deny=user-3
+//
+// Flags whose properties are malformed are disabled.
+package flags
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+)
+
+const (
+	_defaultPrefix = "flags"
+
+	// _buckets is the number of buckets subjects are hashed into, so that
+	// percentages have two decimals.
+	_buckets = 10000
+)
+
+// Reasons an evaluation resulted in its value, as logged.
+const (
+	_reasonOff       = "off"
+	_reasonDenied    = "denied"
+	_reasonAllowed   = "allowed"
+	_reasonRollout   = "rollout"
+	_reasonMalformed = "malformed"
+)
+
+// Default is used by the Enabled function. It holds no flag until replaced by
+// flags of your own.
+var Default = New(config.LoadMap(nil))
+
+// Flags evaluates the feature flags defined in a configuration. It is safe for
+// concurrent use and sees reloads of the configuration.
+type Flags struct {
+	cfg    config.Reader
+	prefix string
+	log    bool
+}
+
+// Option configures Flags.
+type Option func(f *Flags)
+
+// WithPrefix sets the prefix of the properties defining the flags.
+//
+// Default value is "flags".
+func WithPrefix(prefix string) Option {
+	return func(f *Flags) {
+		f.prefix = prefix
+	}
+}
+
+// WithLogging logs every evaluation at debug level, through the logger of the
+// context given to Enabled.
+func WithLogging() Option {
+	return func(f *Flags) {
+		f.log = true
+	}
+}
+
+// New returns the flags defined in cfg, such as a config.Config or a
+// configtest.Config.
+func New(cfg config.Reader, opts ...Option) *Flags {
+	f := &Flags{prefix: _defaultPrefix}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	f.cfg = cfg.Sub(f.prefix)
+
+	return f
+}
+
+// Enabled reports whether the flag called name is enabled for the given
+// subject, using the Default flags.
+func Enabled(ctx context.Context, name, subjectID string) bool {
+	return Default.Enabled(ctx, name, subjectID)
+}
+
+// Enabled reports whether the flag called name is enabled for the given
+// subject, such as a user or a request ID.
+func (f *Flags) Enabled(ctx context.Context, name, subjectID string) bool {
+	enabled, reason := f.evaluate(name, subjectID)
+
+	if f.log {
+		log.Debug(ctx, "feature flag evaluated",
+			log.String("flag", name),
+			log.String("subject", subjectID),
+			log.Bool("enabled", enabled),
+			log.String("reason", reason),
+		)
+	}
+
+	return enabled
+}
+
+func (f *Flags) evaluate(name, subjectID string) (bool, string) {
+	prefix := name + "."
+
+	on, err := f.cfg.LookupBool(prefix + "enabled")
+	if err != nil && !errors.Is(err, config.ErrNotFound) {
+		return false, _reasonMalformed
+	}
+
+	if !on {
+		return false, _reasonOff
+	}
+
+	deny, err := f.list(prefix + "deny")
+	if err != nil {
+		return false, _reasonMalformed
+	}
+
+	if contains(deny, subjectID) {
+		return false, _reasonDenied
+	}
+
+	allow, err := f.list(prefix + "allow")
+	if err != nil {
+		return false, _reasonMalformed
+	}
+
+	if contains(allow, subjectID) {
+		return true, _reasonAllowed
+	}
+
+	percentage, err := f.cfg.LookupFloat64(prefix + "percentage")
+	if errors.Is(err, config.ErrNotFound) {
+		percentage, err = 100, nil
+	}
+
+	if err != nil || percentage < 0 || percentage > 100 {
+		return false, _reasonMalformed
+	}
+
+	return bucket(name, subjectID) < int(math.Round(percentage*_buckets/100)), _reasonRollout
+}
+
+// list returns the values of the list property key, which is empty when
+// missing.
+func (f *Flags) list(key string) ([]string, error) {
+	values, err := f.cfg.LookupStringSlice(key)
+	if errors.Is(err, config.ErrNotFound) {
+		return nil, nil
+	}
+
+	return values, err
+}
+
+// bucket hashes the subject into one of the buckets of the flag. The flag name
+// is part of the hash so that flags rolled out to the same percentage are not
+// enabled for the same subjects.
+func bucket(name, subjectID string) int {
+	sum := sha256.Sum256([]byte(name + "\x00" + subjectID))
+
+	return int(binary.BigEndian.Uint64(sum[:8]) % _buckets)
+}
+
+func contains(values []string, v string) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
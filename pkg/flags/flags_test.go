@@ -0,0 +1,131 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config"
+	"github.com/factory-roraimabits/go-deer/pkg/config/configtest"
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnabled(t *testing.T) {
+	cfg := configtest.Load(map[string]string{
+		"flags.on.enabled":              "true",
+		"flags.off.enabled":             "false",
+		"flags.lists.enabled":           "true",
+		"flags.lists.percentage":        "0",
+		"flags.lists.allow":             "user-1,user-2",
+		"flags.lists.deny":              "user-2",
+		"flags.killed.enabled":          "false",
+		"flags.killed.allow":            "user-1",
+		"flags.malformed.enabled":       "true",
+		"flags.malformed.percentage":    "half",
+		"flags.out-of-range.enabled":    "true",
+		"flags.out-of-range.percentage": "150",
+	})
+
+	f := New(cfg)
+
+	tt := []struct {
+		flag    string
+		subject string
+		enabled bool
+		reason  string
+	}{
+		{flag: "on", subject: "user-1", enabled: true, reason: "rollout"},
+		{flag: "off", subject: "user-1", reason: "off"},
+		{flag: "undefined", subject: "user-1", reason: "off"},
+		{flag: "lists", subject: "user-1", enabled: true, reason: "allowed"},
+		{flag: "lists", subject: "user-2", reason: "denied"},
+		{flag: "lists", subject: "user-3", reason: "rollout"},
+		{flag: "killed", subject: "user-1", reason: "off"},
+		{flag: "malformed", subject: "user-1", reason: "malformed"},
+		{flag: "out-of-range", subject: "user-1", reason: "malformed"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.flag+"/"+tc.subject, func(t *testing.T) {
+			enabled, reason := f.evaluate(tc.flag, tc.subject)
+
+			require.Equal(t, tc.enabled, enabled)
+			require.Equal(t, tc.reason, reason)
+			require.Equal(t, tc.enabled, f.Enabled(context.Background(), tc.flag, tc.subject))
+		})
+	}
+}
+
+func TestEnabled_percentage(t *testing.T) {
+	cfg := config.LoadMap(map[string]string{
+		"features.checkout.enabled":    "true",
+		"features.checkout.percentage": "25",
+		"features.search.enabled":      "true",
+		"features.search.percentage":   "25",
+	})
+
+	f := New(cfg, WithPrefix("features"))
+
+	var checkout, both int
+
+	for i := 0; i < 10000; i++ {
+		subject := fmt.Sprintf("user-%d", i)
+
+		enabled := f.Enabled(context.Background(), "checkout", subject)
+		require.Equal(t, enabled, f.Enabled(context.Background(), "checkout", subject), "evaluations must be stable")
+
+		if enabled {
+			checkout++
+
+			if f.Enabled(context.Background(), "search", subject) {
+				both++
+			}
+		}
+	}
+
+	require.InDelta(t, 2500, checkout, 150)
+	// Flags are rolled out to independent subsets of the subjects.
+	require.InDelta(t, 625, both, 100)
+}
+
+func TestEnabled_default(t *testing.T) {
+	require.False(t, Enabled(context.Background(), "new-checkout", "user-1"))
+
+	defer func(f *Flags) { Default = f }(Default)
+	Default = New(config.LoadMap(map[string]string{"flags.new-checkout.enabled": "true"}))
+
+	require.True(t, Enabled(context.Background(), "new-checkout", "user-1"))
+}
+
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) Sync() error { return nil }
+
+func TestEnabled_logging(t *testing.T) {
+	// Given
+	var out buffer
+
+	lvl := log.NewAtomicLevelAt(log.DebugLevel)
+	ctx := log.Context(context.Background(), log.NewProductionLogger(&lvl, log.WithWriter(&out), log.WithJSONEncoding()))
+
+	f := New(config.LoadMap(map[string]string{"flags.new-checkout.enabled": "true"}), WithLogging())
+
+	// When
+	f.Enabled(ctx, "new-checkout", "user-1")
+
+	// Then
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+
+	require.Equal(t, "debug", line["level"])
+	require.Equal(t, "feature flag evaluated", line["msg"])
+	require.Equal(t, "new-checkout", line["flag"])
+	require.Equal(t, "user-1", line["subject"])
+	require.Equal(t, true, line["enabled"])
+	require.Equal(t, "rollout", line["reason"])
+}
@@ -0,0 +1,192 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// Properties configuring a logger built by NewFromConfig.
+const (
+	LevelProperty      = "log.level"
+	EncodingProperty   = "log.encoding"
+	CallerProperty     = "log.caller"
+	StacktraceProperty = "log.stacktrace"
+	OutputProperty     = "log.output"
+)
+
+// Properties is the part of a configuration NewFromConfig reads, satisfied by
+// both config.Config and configtest.Config.
+//
+// It is declared here rather than depending on package config, which logs
+// through this package.
+type Properties interface {
+	// Has reports whether the property exists
+	Has(key string) bool
+
+	// LookupString retrieve the property as string value
+	LookupString(key string) (string, error)
+
+	// LookupBool retrieve the property as bool value
+	LookupBool(key string) (bool, error)
+}
+
+var (
+	_levels = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel}
+
+	_encodings = map[string]Option{
+		"kv":      WithKeyValueEncoding(),
+		"json":    WithJSONEncoding(),
+		"console": WithConsoleEncoding(),
+	}
+	_encodingNames = []string{"kv", "json", "console"}
+
+	// Globally declare the stdout writer, as done for stderr.
+	_stdout = zapcore.Lock(zapcore.AddSync(os.Stdout))
+)
+
+// NewFromConfig builds a production logger, see NewProductionLogger, from the
+// following properties of cfg, all of them optional:
+//
+//   - log.level: the minimum level logged, one of debug, info (the default),
+//     warn, error, dpanic, panic and fatal.
+//   - log.encoding: kv (the default), json or console.
+//   - log.caller: whether to include the caller, true by default, read as
+//     LookupBool does.
+//   - log.stacktrace: whether to include a stacktrace on errors, true by
+//     default, read as LookupBool does.
+//   - log.output: stderr (the default), stdout or the path of a file the logs
+//     are appended to. The file is never closed, as the logger may be used
+//     until the program exits, so build the logger once rather than on every
+//     configuration reload.
+//
+// opts are applied after the options read from cfg. It returns the level of
+// the logger along with it, so that it can be adjusted at runtime. Every
+// invalid property is reported, along with its valid values.
+func NewFromConfig(cfg Properties, opts ...Option) (Logger, AtomicLevel, error) {
+	lvl := NewAtomicLevel()
+
+	var (
+		cfgOpts []Option
+		errs    []error
+	)
+
+	if v, ok, err := lookup(cfg, LevelProperty); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		var l Level
+		if err := l.UnmarshalText([]byte(strings.ToLower(v))); err != nil {
+			errs = append(errs, invalid(LevelProperty, v, levelNames()))
+		}
+
+		lvl.SetLevel(l)
+	}
+
+	if v, ok, err := lookup(cfg, EncodingProperty); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		opt, found := _encodings[strings.ToLower(v)]
+		if !found {
+			errs = append(errs, invalid(EncodingProperty, v, _encodingNames))
+		}
+
+		cfgOpts = append(cfgOpts, opt)
+	}
+
+	for _, flag := range []struct {
+		key string
+		opt func(bool) Option
+	}{
+		{key: CallerProperty, opt: WithCaller},
+		{key: StacktraceProperty, opt: WithStacktraceOnError},
+	} {
+		if _, ok, err := lookup(cfg, flag.key); err != nil {
+			errs = append(errs, err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		b, err := cfg.LookupBool(flag.key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		cfgOpts = append(cfgOpts, flag.opt(b))
+	}
+
+	if v, ok, err := lookup(cfg, OutputProperty); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		if len(errs) > 0 {
+			// Don't open a file for a logger that won't be built.
+			return nil, lvl, fmt.Errorf("configuring logger: %w", multierr.Combine(errs...))
+		}
+
+		w, err := openOutput(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %v", OutputProperty, err))
+		}
+
+		cfgOpts = append(cfgOpts, WithWriter(w))
+	}
+
+	if err := multierr.Combine(errs...); err != nil {
+		return nil, lvl, fmt.Errorf("configuring logger: %w", err)
+	}
+
+	return NewProductionLogger(&lvl, append(cfgOpts, opts...)...), lvl, nil
+}
+
+// lookup returns the trimmed value of key, and false when it's missing or
+// empty.
+func lookup(cfg Properties, key string) (string, bool, error) {
+	if !cfg.Has(key) {
+		return "", false, nil
+	}
+
+	v, err := cfg.LookupString(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	v = strings.TrimSpace(v)
+
+	return v, v != "", nil
+}
+
+// levelNames returns the names of the levels, from the lowest.
+func levelNames() []string {
+	names := make([]string, len(_levels))
+	for i, l := range _levels {
+		names[i] = l.String()
+	}
+
+	return names
+}
+
+// openOutput returns the writer named by v, opening files in append mode. The
+// files opened are never closed, see NewFromConfig.
+func openOutput(v string) (WriteSyncer, error) {
+	switch strings.ToLower(v) {
+	case "stderr":
+		return _stderr, nil
+	case "stdout":
+		return _stdout, nil
+	}
+
+	f, err := os.OpenFile(v, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("expected stderr, stdout or a file path: %v", err)
+	}
+
+	return zapcore.Lock(f), nil
+}
+
+func invalid(key, v string, choices []string) error {
+	return fmt.Errorf("key %s: invalid value %q, expected one of %s", key, v, strings.Join(choices, ", "))
+}
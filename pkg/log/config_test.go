@@ -0,0 +1,110 @@
+package log_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/factory-roraimabits/go-deer/pkg/config/configtest"
+	"github.com/factory-roraimabits/go-deer/pkg/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	// Given
+	output := filepath.Join(t.TempDir(), "app.log")
+	cfg := configtest.Load(map[string]string{
+		"log.level":      "WARN",
+		"log.encoding":   "json",
+		"log.caller":     "off",
+		"log.stacktrace": "no",
+		"log.output":     output,
+	})
+
+	// When
+	logger, lvl, err := log.NewFromConfig(cfg)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, log.WarnLevel, lvl.Level())
+
+	logger.Info("skipped")
+	logger.Error("logged", log.String("key", "value"))
+
+	lvl.SetLevel(log.InfoLevel)
+	logger.Info("logged after SetLevel")
+
+	b, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	require.Equal(t, "error", entry["level"])
+	require.Equal(t, "logged", entry["msg"])
+	require.Equal(t, "value", entry["key"])
+	require.NotContains(t, entry, "caller")
+	require.NotContains(t, entry, "stacktrace")
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	require.Equal(t, "logged after SetLevel", entry["msg"])
+}
+
+func TestNewFromConfig_defaults(t *testing.T) {
+	// When
+	logger, lvl, err := log.NewFromConfig(configtest.Load(map[string]string{}))
+
+	// Then
+	require.NoError(t, err)
+	require.NotNil(t, logger)
+	require.Equal(t, log.InfoLevel, lvl.Level())
+}
+
+func TestNewFromConfig_err(t *testing.T) {
+	tt := []struct {
+		name          string
+		props         map[string]string
+		expectedError string
+	}{
+		{
+			name:          "invalid level",
+			props:         map[string]string{"log.level": "verbose"},
+			expectedError: `configuring logger: key log.level: invalid value "verbose", expected one of debug, info, warn, error, dpanic, panic, fatal`,
+		},
+		{
+			name:          "invalid encoding",
+			props:         map[string]string{"log.encoding": "xml"},
+			expectedError: `configuring logger: key log.encoding: invalid value "xml", expected one of kv, json, console`,
+		},
+		{
+			name:  "invalid flags",
+			props: map[string]string{"log.caller": "yes please", "log.stacktrace": "never"},
+			expectedError: `configuring logger: key log.caller: malformed value "yes please": expected one of 1, true, yes, on, 0, false, no or off; ` +
+				`key log.stacktrace: malformed value "never": expected one of 1, true, yes, on, 0, false, no or off`,
+		},
+		{
+			name:          "strconv-only flag",
+			props:         map[string]string{"log.caller": "t"},
+			expectedError: `configuring logger: key log.caller: malformed value "t": expected one of 1, true, yes, on, 0, false, no or off`,
+		},
+		{
+			name:          "invalid output",
+			props:         map[string]string{"log.output": "/non-existent/app.log"},
+			expectedError: "configuring logger: key log.output: expected stderr, stdout or a file path: open /non-existent/app.log: no such file or directory",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			logger, _, err := log.NewFromConfig(configtest.Load(tc.props))
+
+			// Then
+			require.EqualError(t, err, tc.expectedError)
+			require.Nil(t, logger)
+		})
+	}
+}